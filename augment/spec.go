@@ -0,0 +1,260 @@
+// Package augment implements data augmentation for
+// speech, so that models can be trained on randomly
+// perturbed copies of a small dataset.
+//
+// WaveAugment perturbs audio before features are
+// computed, and SpecAugment perturbs the features
+// themselves.
+//
+// Augmentation is meant to be applied on the fly, every
+// time a sample is used for training, rather than as a
+// preprocessing step.
+package augment
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/unixpickle/num-analysis/linalg"
+	"github.com/unixpickle/speechrecog/mfcc"
+)
+
+// DefaultMaxTimeFraction is the default value for
+// SpecAugmentOptions.MaxTimeFraction.
+const DefaultMaxTimeFraction = 1
+
+// SpecAugmentOptions stores the configuration options
+// for SpecAugment.
+//
+// The names follow Park et al. (2019), "SpecAugment: A
+// Simple Data Augmentation Method for Automatic Speech
+// Recognition".
+// Every augmentation is disabled by default.
+type SpecAugmentOptions struct {
+	// TimeWarp is the largest number of frames by which a
+	// random point in time may be moved forward or back,
+	// stretching the frames on either side of it.
+	// If this is 0, time is not warped.
+	TimeWarp int
+
+	// FreqMasks is the number of frequency masks, each of
+	// which covers a random range of up to MaxFreqMask
+	// consecutive coefficients.
+	FreqMasks   int
+	MaxFreqMask int
+
+	// TimeMasks is the number of time masks, each of
+	// which covers a random range of up to MaxTimeMask
+	// consecutive frames.
+	TimeMasks   int
+	MaxTimeMask int
+
+	// MaxTimeFraction further limits each time mask to
+	// this fraction of the sequence's length.
+	// If this is 0, DefaultMaxTimeFraction is used.
+	MaxTimeFraction float64
+
+	// Seed seeds the random number generator.
+	Seed int64
+}
+
+// SpecAugment randomly warps and masks sequences of
+// feature vectors.
+//
+// Masked entries are replaced with the mean of the
+// corresponding coefficient over the whole sequence,
+// which is zero for normalized features.
+//
+// It is safe to use a SpecAugment from multiple
+// goroutines, although the order in which they draw
+// random numbers is then unpredictable.
+type SpecAugment struct {
+	options SpecAugmentOptions
+
+	lock sync.Mutex
+	gen  *rand.Rand
+}
+
+// NewSpecAugment creates a SpecAugment with the given
+// options.
+func NewSpecAugment(options *SpecAugmentOptions) *SpecAugment {
+	if options == nil {
+		options = &SpecAugmentOptions{}
+	}
+	return &SpecAugment{
+		options: *options,
+		gen:     rand.New(rand.NewSource(options.Seed)),
+	}
+}
+
+// Apply generates an augmented copy of a sequence.
+// The sequence itself is not modified.
+//
+// All of the vectors must have the same length.
+func (s *SpecAugment) Apply(seq []linalg.Vector) []linalg.Vector {
+	if len(seq) == 0 {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	res := s.timeWarp(seq)
+	mean := make([]float64, len(res[0]))
+	for _, vec := range res {
+		for i, x := range vec {
+			mean[i] += x / float64(len(res))
+		}
+	}
+
+	opts := &s.options
+	for i := 0; i < opts.FreqMasks; i++ {
+		start, end := s.randomRange(len(mean), opts.MaxFreqMask)
+		for _, vec := range res {
+			copy(vec[start:end], mean[start:end])
+		}
+	}
+
+	fraction := opts.MaxTimeFraction
+	if fraction == 0 {
+		fraction = DefaultMaxTimeFraction
+	}
+	maxWidth := opts.MaxTimeMask
+	if limit := int(fraction * float64(len(res))); limit < maxWidth {
+		maxWidth = limit
+	}
+	for i := 0; i < opts.TimeMasks; i++ {
+		start, end := s.randomRange(len(res), maxWidth)
+		for _, vec := range res[start:end] {
+			copy(vec, mean)
+		}
+	}
+
+	return res
+}
+
+// timeWarp moves a random frame forward or back,
+// linearly stretching the frames on either side.
+// The result is always a new sequence.
+func (s *SpecAugment) timeWarp(seq []linalg.Vector) []linalg.Vector {
+	res := make([]linalg.Vector, len(seq))
+
+	// The warp point must stay more than maxWarp frames
+	// from either end, so that both of the stretched
+	// pieces keep at least one frame.
+	maxWarp := s.options.TimeWarp
+	if limit := (len(seq) - 3) / 2; limit < maxWarp {
+		maxWarp = limit
+	}
+	if maxWarp <= 0 {
+		for i, vec := range seq {
+			res[i] = append(linalg.Vector{}, vec...)
+		}
+		return res
+	}
+
+	center := maxWarp + 1 + s.gen.Intn(len(seq)-2-2*maxWarp)
+	dest := center + s.gen.Intn(2*maxWarp+1) - maxWarp
+	for i := range res {
+		var pos float64
+		if i < dest {
+			pos = float64(i) * float64(center) / float64(dest)
+		} else {
+			pos = float64(center) + float64(i-dest)*float64(len(seq)-1-center)/
+				float64(len(seq)-1-dest)
+		}
+		res[i] = interpolateFrames(seq, pos)
+	}
+	return res
+}
+
+// randomRange picks a random range of up to maxWidth
+// indices in [0, size).
+func (s *SpecAugment) randomRange(size, maxWidth int) (start, end int) {
+	if maxWidth > size {
+		maxWidth = size
+	}
+	if maxWidth <= 0 {
+		return 0, 0
+	}
+	width := s.gen.Intn(maxWidth + 1)
+	start = s.gen.Intn(size - width + 1)
+	return start, start + width
+}
+
+func interpolateFrames(seq []linalg.Vector, pos float64) linalg.Vector {
+	idx := int(math.Floor(pos))
+	if idx >= len(seq)-1 {
+		return append(linalg.Vector{}, seq[len(seq)-1]...)
+	}
+	frac := pos - float64(idx)
+	res := make(linalg.Vector, len(seq[idx]))
+	for i, x := range seq[idx] {
+		res[i] = x*(1-frac) + seq[idx+1][i]*frac
+	}
+	return res
+}
+
+// AugmentCoeffs generates a CoeffSource which applies a
+// SpecAugment to the output of another CoeffSource.
+// Each output frame keeps the timing of the input frame
+// in the same position.
+//
+// Since warping and masking depend on the whole
+// sequence, the first call to NextCoeffs reads every
+// vector from the wrapped source.
+// If the wrapped source fails with an error other than
+// io.EOF, that error is returned and the vectors before
+// it are dropped.
+func AugmentCoeffs(source mfcc.CoeffSource, augment *SpecAugment) mfcc.FrameSource {
+	return &specSource{source: mfcc.Frames(source), augment: augment}
+}
+
+type specSource struct {
+	source  mfcc.FrameSource
+	augment *SpecAugment
+
+	read      bool
+	output    []*mfcc.Frame
+	doneError error
+}
+
+func (s *specSource) NextCoeffs() ([]float64, error) {
+	frame, err := s.NextFrame()
+	if err != nil {
+		return nil, err
+	}
+	return frame.Coeffs, nil
+}
+
+func (s *specSource) NextFrame() (*mfcc.Frame, error) {
+	if !s.read {
+		s.read = true
+		var seq []linalg.Vector
+		for {
+			frame, err := s.source.NextFrame()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				s.doneError = err
+				s.output = nil
+				return nil, err
+			}
+			s.output = append(s.output, frame)
+			seq = append(seq, frame.Coeffs)
+		}
+		for i, vec := range s.augment.Apply(seq) {
+			frame := *s.output[i]
+			frame.Coeffs = vec
+			s.output[i] = &frame
+		}
+		s.doneError = io.EOF
+	}
+	if len(s.output) == 0 {
+		return nil, s.doneError
+	}
+	res := s.output[0]
+	s.output = s.output[1:]
+	return res, nil
+}
@@ -0,0 +1,195 @@
+package augment
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+func TestSpecAugmentIdentity(t *testing.T) {
+	seq := randomSequence(20, 5)
+	res := NewSpecAugment(nil).Apply(seq)
+	if !sequencesEqual(res, seq) {
+		t.Error("sequence should be unchanged")
+	}
+	res[0][0] = 1000
+	if seq[0][0] == 1000 {
+		t.Error("result should be a copy")
+	}
+}
+
+func TestSpecAugmentFreqMask(t *testing.T) {
+	seq := randomSequence(30, 10)
+	mean := sequenceMean(seq)
+	aug := NewSpecAugment(&SpecAugmentOptions{FreqMasks: 1, MaxFreqMask: 4})
+	for trial := 0; trial < 20; trial++ {
+		res := aug.Apply(seq)
+		var masked int
+		for i := range mean {
+			changed := false
+			for j, vec := range res {
+				if vec[i] != seq[j][i] {
+					changed = true
+					if math.Abs(vec[i]-mean[i]) > 1e-8 {
+						t.Fatalf("masked entry should be %f but got %f", mean[i], vec[i])
+					}
+				}
+			}
+			if changed {
+				masked++
+			}
+		}
+		if masked > 4 {
+			t.Fatalf("masked %d coefficients", masked)
+		}
+	}
+}
+
+func TestSpecAugmentTimeMask(t *testing.T) {
+	seq := randomSequence(30, 10)
+	mean := sequenceMean(seq)
+	aug := NewSpecAugment(&SpecAugmentOptions{
+		TimeMasks:       2,
+		MaxTimeMask:     20,
+		MaxTimeFraction: 0.1,
+	})
+	for trial := 0; trial < 20; trial++ {
+		res := aug.Apply(seq)
+		var masked int
+		for j, vec := range res {
+			if !sequencesEqual([]linalg.Vector{vec}, seq[j:j+1]) {
+				masked++
+				if !sequencesEqual([]linalg.Vector{vec}, []linalg.Vector{mean}) {
+					t.Fatalf("frame %d should be the mean", j)
+				}
+			}
+		}
+		if masked > 6 {
+			t.Fatalf("masked %d frames", masked)
+		}
+	}
+}
+
+func TestSpecAugmentTimeWarp(t *testing.T) {
+	seq := make([]linalg.Vector, 50)
+	for i := range seq {
+		seq[i] = linalg.Vector{float64(i), -float64(i)}
+	}
+	aug := NewSpecAugment(&SpecAugmentOptions{TimeWarp: 5})
+	var warped bool
+	for trial := 0; trial < 20; trial++ {
+		res := aug.Apply(seq)
+		if len(res) != len(seq) {
+			t.Fatalf("expected %d frames but got %d", len(seq), len(res))
+		}
+		if res[0][0] != 0 || math.Abs(res[len(res)-1][0]-float64(len(seq)-1)) > 1e-8 {
+			t.Fatalf("endpoints moved: %v %v", res[0], res[len(res)-1])
+		}
+		for i, vec := range res {
+			if vec[1] != -vec[0] {
+				t.Fatalf("frame %d is not an interpolation: %v", i, vec)
+			}
+			if i > 0 && vec[0] <= res[i-1][0] {
+				t.Fatalf("frame %d is out of order", i)
+			}
+			if math.Abs(vec[0]-float64(i)) > 1e-8 {
+				warped = true
+			}
+		}
+	}
+	if !warped {
+		t.Error("time was never warped")
+	}
+
+	short := seq[:3]
+	if res := aug.Apply(short); !sequencesEqual(res, short) {
+		t.Error("short sequences should not be warped")
+	}
+}
+
+func TestSpecAugmentSeed(t *testing.T) {
+	seq := randomSequence(40, 8)
+	options := &SpecAugmentOptions{
+		TimeWarp:    4,
+		FreqMasks:   2,
+		MaxFreqMask: 3,
+		TimeMasks:   2,
+		MaxTimeMask: 5,
+		Seed:        1337,
+	}
+	res1 := NewSpecAugment(options).Apply(seq)
+	res2 := NewSpecAugment(options).Apply(seq)
+	if !sequencesEqual(res1, res2) {
+		t.Error("same seed should give same results")
+	}
+
+	source := AugmentCoeffs(&sliceCoeffSource{vecs: seq}, NewSpecAugment(options))
+	var res3 []linalg.Vector
+	for {
+		vec, err := source.NextCoeffs()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		res3 = append(res3, vec)
+	}
+	if !sequencesEqual(res1, res3) {
+		t.Error("source should match Apply")
+	}
+}
+
+type sliceCoeffSource struct {
+	vecs []linalg.Vector
+}
+
+func (s *sliceCoeffSource) NextCoeffs() ([]float64, error) {
+	if len(s.vecs) == 0 {
+		return nil, io.EOF
+	}
+	res := s.vecs[0]
+	s.vecs = s.vecs[1:]
+	return res, nil
+}
+
+func randomSequence(length, size int) []linalg.Vector {
+	gen := rand.New(rand.NewSource(42))
+	res := make([]linalg.Vector, length)
+	for i := range res {
+		res[i] = make(linalg.Vector, size)
+		for j := range res[i] {
+			res[i][j] = gen.NormFloat64()
+		}
+	}
+	return res
+}
+
+func sequenceMean(seq []linalg.Vector) linalg.Vector {
+	res := make(linalg.Vector, len(seq[0]))
+	for _, vec := range seq {
+		for i, x := range vec {
+			res[i] += x / float64(len(seq))
+		}
+	}
+	return res
+}
+
+func sequencesEqual(s1, s2 []linalg.Vector) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i, v1 := range s1 {
+		if len(v1) != len(s2[i]) {
+			return false
+		}
+		for j, x := range v1 {
+			if math.Abs(x-s2[i][j]) > 1e-8 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,356 @@
+package augment
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sync"
+
+	"github.com/unixpickle/speechrecog/mfcc"
+	"github.com/unixpickle/speechrecog/wavio"
+)
+
+// minReverbFFT is the smallest FFT size used for
+// convolution, which keeps the blocks for short impulse
+// responses from being tiny.
+const minReverbFFT = 1024
+
+// WaveAugment randomly perturbs audio.
+//
+// Each method makes all of its random choices when it is
+// called, so the results only depend on the seed and the
+// order of the calls, not on how the returned Sources
+// are read.
+//
+// It is safe to use a WaveAugment from multiple
+// goroutines, although the order in which they draw
+// random numbers is then unpredictable.
+type WaveAugment struct {
+	lock sync.Mutex
+	gen  *rand.Rand
+}
+
+// NewWaveAugment creates a WaveAugment with a seeded
+// random number generator.
+func NewWaveAugment(seed int64) *WaveAugment {
+	return &WaveAugment{gen: rand.New(rand.NewSource(seed))}
+}
+
+// Noise mixes noise into clean audio with an SNR chosen
+// uniformly between minSNR and maxSNR decibels.
+// See AddNoise for details.
+func (w *WaveAugment) Noise(clean, noise mfcc.Source, minSNR, maxSNR float64) mfcc.Source {
+	return AddNoise(clean, noise, minSNR+w.float64()*(maxSNR-minSNR))
+}
+
+// Speed changes the speed of audio by a factor chosen
+// uniformly from factors, such as {0.9, 1, 1.1}.
+// See ChangeSpeed for details.
+func (w *WaveAugment) Speed(s mfcc.Source, factors []float64) mfcc.Source {
+	return ChangeSpeed(s, factors[w.intn(len(factors))])
+}
+
+// Volume scales audio by a gain chosen uniformly between
+// -maxDB and maxDB decibels.
+func (w *WaveAugment) Volume(s mfcc.Source, maxDB float64) mfcc.Source {
+	db := (2*w.float64() - 1) * maxDB
+	return mfcc.Gain(s, math.Pow(10, db/20))
+}
+
+// Reverb convolves audio with an impulse response chosen
+// uniformly from rirs.
+// See Reverb for details.
+func (w *WaveAugment) Reverb(s mfcc.Source, rirs [][]float64) mfcc.Source {
+	return Reverb(s, rirs[w.intn(len(rirs))])
+}
+
+func (w *WaveAugment) float64() float64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.gen.Float64()
+}
+
+func (w *WaveAugment) intn(n int) int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.gen.Intn(n)
+}
+
+// AddNoise generates a Source which mixes noise into
+// clean audio, scaling the noise so that the result has
+// the given signal-to-noise ratio in decibels.
+//
+// The noise is repeated if it is shorter than the clean
+// audio, and cut off if it is longer.
+// If either one is silent, the clean audio is returned
+// unchanged.
+//
+// Since the SNR depends on the power of the whole
+// signal, the first read consumes all of the clean audio.
+func AddNoise(clean, noise mfcc.Source, snr float64) mfcc.Source {
+	return &noiseSource{clean: clean, noise: noise, snr: snr}
+}
+
+type noiseSource struct {
+	clean mfcc.Source
+	noise mfcc.Source
+	snr   float64
+
+	output    *mfcc.SliceSource
+	doneError error
+}
+
+func (n *noiseSource) ReadSamples(s []float64) (int, error) {
+	if n.doneError != nil {
+		return 0, n.doneError
+	}
+	if n.output == nil {
+		if err := n.mix(); err != nil {
+			n.doneError = err
+			return 0, err
+		}
+	}
+	return n.output.ReadSamples(s)
+}
+
+func (n *noiseSource) mix() error {
+	clean, err := readSamples(n.clean, -1)
+	if err != nil {
+		return err
+	}
+	n.output = &mfcc.SliceSource{Slice: clean}
+	if len(clean) == 0 {
+		return nil
+	}
+	noise, err := readSamples(n.noise, len(clean))
+	if err != nil {
+		return err
+	}
+	if len(noise) == 0 {
+		return nil
+	}
+
+	var cleanPower, noisePower float64
+	for i, x := range clean {
+		y := noise[i%len(noise)]
+		cleanPower += x * x
+		noisePower += y * y
+	}
+	if cleanPower == 0 || noisePower == 0 {
+		return nil
+	}
+	gain := math.Sqrt(cleanPower / (noisePower * math.Pow(10, n.snr/10)))
+	for i := range clean {
+		clean[i] += gain * noise[i%len(noise)]
+	}
+	return nil
+}
+
+// ChangeSpeed generates a Source which plays audio
+// faster or slower by the given factor, by resampling it
+// and keeping the original sample rate.
+// As with speed perturbation in Kaldi, this changes both
+// the tempo and the pitch.
+//
+// For example, a factor of 1.1 makes the audio about 9%
+// shorter.
+func ChangeSpeed(s mfcc.Source, factor float64) mfcc.Source {
+	return mfcc.Resample(s, 1/factor, mfcc.MediumQuality)
+}
+
+// Reverb generates a Source which convolves audio with a
+// room impulse response (RIR).
+//
+// The impulse response is scaled to unit energy, and the
+// output is shifted so that the strongest peak of the
+// impulse response, which is usually the direct path,
+// does not delay the audio.
+// The output has as many samples as the input, so it
+// lines up with labels for the clean audio.
+//
+// The convolution is computed with FFTs, one block at a
+// time, so memory use depends only on the length of the
+// impulse response.
+func Reverb(s mfcc.Source, rir []float64) mfcc.Source {
+	if len(rir) == 0 {
+		panic("empty impulse response")
+	}
+	fftSize := minReverbFFT
+	for fftSize < 2*len(rir) {
+		fftSize *= 2
+	}
+
+	var norm float64
+	var delay int
+	for i, x := range rir {
+		norm += x * x
+		if math.Abs(x) > math.Abs(rir[delay]) {
+			delay = i
+		}
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		norm = 1
+	}
+
+	plan := mfcc.NewFFTPlan(fftSize)
+	filter := make([]complex128, fftSize)
+	for i, x := range rir {
+		filter[i] = complex(x/norm, 0)
+	}
+	plan.Transform(filter, filter)
+
+	return &reverbSource{
+		source:    s,
+		plan:      plan,
+		filter:    filter,
+		blockSize: fftSize - len(rir) + 1,
+		tail:      make([]float64, len(rir)-1),
+		delay:     delay,
+		block:     make([]float64, fftSize-len(rir)+1),
+		scratch:   make([]complex128, fftSize),
+	}
+}
+
+type reverbSource struct {
+	source    mfcc.Source
+	plan      *mfcc.FFTPlan
+	filter    []complex128
+	blockSize int
+
+	// tail stores the parts of the convolutions of past
+	// blocks which overlap future blocks.
+	tail []float64
+
+	// pending stores convolved samples which have not
+	// been returned, starting at index outputIdx of the
+	// full convolution.
+	pending   []float64
+	outputIdx int
+
+	delay      int
+	inputCount int
+	inputDone  bool
+	doneError  error
+
+	block   []float64
+	scratch []complex128
+}
+
+func (r *reverbSource) ReadSamples(s []float64) (n int, err error) {
+	for n < len(s) {
+		if len(r.pending) == 0 {
+			if r.inputDone {
+				break
+			}
+			r.convolveBlock()
+			continue
+		}
+		x := r.pending[0]
+		r.pending = r.pending[1:]
+		idx := r.outputIdx
+		r.outputIdx++
+		if idx < r.delay {
+			continue
+		} else if idx >= r.delay+r.inputCount {
+			// This is only possible once the input has
+			// ended and the rest of the tail is unused.
+			r.pending = nil
+			continue
+		}
+		s[n] = x
+		n++
+	}
+	if n < len(s) {
+		if r.doneError != nil {
+			return n, r.doneError
+		}
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// convolveBlock reads the next block of input and adds
+// its convolution to pending.
+func (r *reverbSource) convolveBlock() {
+	var have int
+	var err error
+	for have < r.blockSize && err == nil {
+		var read int
+		read, err = r.source.ReadSamples(r.block[have:])
+		have += read
+	}
+	r.inputCount += have
+
+	if have > 0 {
+		for i := range r.scratch {
+			if i < have {
+				r.scratch[i] = complex(r.block[i], 0)
+			} else {
+				r.scratch[i] = 0
+			}
+		}
+		r.plan.Transform(r.scratch, r.scratch)
+		for i, x := range r.filter {
+			r.scratch[i] *= x
+		}
+		r.plan.Inverse(r.scratch, r.scratch)
+
+		// The convolution of the block spans have+len(tail)
+		// samples, the first of which overlap the tail.
+		conv := make([]float64, have+len(r.tail))
+		for i := range conv {
+			conv[i] = real(r.scratch[i])
+		}
+		for i, x := range r.tail {
+			conv[i] += x
+		}
+		r.pending = append(r.pending, conv[:have]...)
+		copy(r.tail, conv[have:])
+	}
+
+	if err != nil {
+		r.inputDone = true
+		r.pending = append(r.pending, r.tail...)
+		if err != io.EOF {
+			r.doneError = err
+		}
+	}
+}
+
+// LoadImpulseResponse reads a room impulse response from
+// a WAV file, resampling it to the given sample rate if
+// necessary.
+// Only the first channel is used.
+func LoadImpulseResponse(path string, sampleRate int) ([]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	reader, err := wavio.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+	source := mfcc.SelectChannel(reader, reader.Channels(), 0)
+	if reader.SampleRate() != sampleRate {
+		ratio := float64(sampleRate) / float64(reader.SampleRate())
+		source = mfcc.Resample(source, ratio, mfcc.HighQuality)
+	}
+	return readSamples(source, -1)
+}
+
+// readSamples reads up to max samples from a Source, or
+// every sample if max is negative.
+// It returns an error if the Source fails with something
+// other than io.EOF.
+func readSamples(s mfcc.Source, max int) ([]float64, error) {
+	var sink mfcc.SliceSink
+	if max >= 0 {
+		s = mfcc.Limit(s, max)
+	}
+	_, err := mfcc.Copy(&sink, s)
+	return sink.Slice, err
+}
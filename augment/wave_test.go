@@ -0,0 +1,191 @@
+package augment
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unixpickle/speechrecog/mfcc"
+	"github.com/unixpickle/speechrecog/wavio"
+)
+
+func TestAddNoise(t *testing.T) {
+	clean := randomSignal(1000, 1)
+	noise := randomSignal(300, 2)
+	for _, snr := range []float64{-5, 0, 10} {
+		mixed := readAll(t, AddNoise(&mfcc.SliceSource{Slice: clean},
+			&mfcc.SliceSource{Slice: noise}, snr))
+		if len(mixed) != len(clean) {
+			t.Fatalf("expected %d samples but got %d", len(clean), len(mixed))
+		}
+		var cleanPower, noisePower float64
+		for i, x := range clean {
+			cleanPower += x * x
+			noisePower += (mixed[i] - x) * (mixed[i] - x)
+		}
+		actual := 10 * math.Log10(cleanPower/noisePower)
+		if math.Abs(actual-snr) > 1e-5 {
+			t.Errorf("expected SNR %f but got %f", snr, actual)
+		}
+
+		// The noise should be repeated.
+		scale := (mixed[0] - clean[0]) / noise[0]
+		if diff := mixed[300] - clean[300] - scale*noise[0]; math.Abs(diff) > 1e-8 {
+			t.Errorf("noise was not repeated (diff %f)", diff)
+		}
+	}
+
+	silent := readAll(t, AddNoise(&mfcc.SliceSource{Slice: clean},
+		&mfcc.SliceSource{Slice: make([]float64, 10)}, 0))
+	if !signalsClose(silent, clean) {
+		t.Error("silent noise should not change the audio")
+	}
+}
+
+func TestChangeSpeed(t *testing.T) {
+	signal := randomSignal(1000, 3)
+	for _, factor := range []float64{0.9, 1, 1.1} {
+		actual := readAll(t, ChangeSpeed(&mfcc.SliceSource{Slice: signal}, factor))
+		expected := int(math.Ceil(1000 / factor))
+		if len(actual) != expected {
+			t.Errorf("factor %f: expected %d samples but got %d", factor, expected,
+				len(actual))
+		}
+	}
+}
+
+func TestReverb(t *testing.T) {
+	signal := randomSignal(5000, 4)
+	rir := randomSignal(700, 5)
+	rir[30] = 10
+
+	actual := readAll(t, Reverb(&sliceSource{vec: signal, buffSize: 333}, rir))
+
+	var norm float64
+	for _, x := range rir {
+		norm += x * x
+	}
+	norm = math.Sqrt(norm)
+	expected := make([]float64, len(signal))
+	for i := range expected {
+		for j, x := range rir {
+			if k := i + 30 - j; k >= 0 && k < len(signal) {
+				expected[i] += signal[k] * x / norm
+			}
+		}
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d samples but got %d", len(expected), len(actual))
+	}
+	if !signalsClose(actual, expected) {
+		t.Error("incorrect convolution")
+	}
+}
+
+func TestLoadImpulseResponse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "augment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rir.wav")
+
+	w, err := wavio.Create(path, wavio.Format{
+		SampleRate:    8000,
+		Channels:      2,
+		BitsPerSample: 32,
+		Float:         true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteSamples([]float64{1, -1, 0.5, -1, 0.25, -1})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rir, err := LoadImpulseResponse(path, 8000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []float64{1, 0.5, 0.25}; !signalsClose(rir, expected) {
+		t.Errorf("expected %v but got %v", expected, rir)
+	}
+	rir, err = LoadImpulseResponse(path, 16000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rir) != 6 {
+		t.Errorf("expected 6 resampled samples but got %d", len(rir))
+	}
+}
+
+func TestWaveAugmentSeed(t *testing.T) {
+	signal := randomSignal(2000, 6)
+	noise := randomSignal(2000, 7)
+	rirs := [][]float64{{1, 0.5}, {1, 0, 0, -0.3}}
+	augment := func(seed int64) []float64 {
+		w := NewWaveAugment(seed)
+		var s mfcc.Source = &mfcc.SliceSource{Slice: signal}
+		s = w.Speed(s, []float64{0.9, 1, 1.1})
+		s = w.Reverb(s, rirs)
+		s = w.Noise(s, &mfcc.SliceSource{Slice: noise}, 5, 20)
+		s = w.Volume(s, 6)
+		return readAll(t, s)
+	}
+	res1 := augment(1337)
+	res2 := augment(1337)
+	if len(res1) != len(res2) || !signalsClose(res1, res2) {
+		t.Error("same seed should give same results")
+	}
+}
+
+type sliceSource struct {
+	vec      []float64
+	buffSize int
+}
+
+func (s *sliceSource) ReadSamples(out []float64) (int, error) {
+	if len(out) > s.buffSize {
+		out = out[:s.buffSize]
+	}
+	n := copy(out, s.vec)
+	s.vec = s.vec[n:]
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func readAll(t *testing.T, s mfcc.Source) []float64 {
+	var sink mfcc.SliceSink
+	if _, err := mfcc.Copy(&sink, s); err != nil {
+		t.Fatal(err)
+	}
+	return sink.Slice
+}
+
+func randomSignal(size int, seed int64) []float64 {
+	gen := rand.New(rand.NewSource(seed))
+	res := make([]float64, size)
+	for i := range res {
+		res[i] = gen.Float64()*2 - 1
+	}
+	return res
+}
+
+func signalsClose(s1, s2 []float64) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i, x := range s1 {
+		if math.Abs(x-s2[i]) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
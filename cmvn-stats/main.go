@@ -0,0 +1,134 @@
+// Command cmvn-stats computes global cepstral mean and
+// variance statistics for the recordings in a speech
+// data directory.
+//
+// The resulting file can be loaded with
+// mfcc.LoadCMVNStats and used with mfcc.NormalizeGlobal.
+// It also records the feature options given on the
+// command line, under the "Features" key, since the
+// statistics only apply to features computed the same way.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/unixpickle/speechrecog/mfcc"
+	"github.com/unixpickle/speechrecog/speechdata"
+	"github.com/unixpickle/speechrecog/wavio"
+)
+
+// featureConfig stores the options used to compute the
+// features behind a set of statistics.
+// Zero values select the mfcc package's defaults.
+type featureConfig struct {
+	Window      time.Duration
+	Overlap     time.Duration
+	FFTSize     int
+	MelCount    int
+	KeepCount   int
+	PreEmphasis float64
+	Lifter      float64
+	Hamming     bool
+	Deltas      int
+	DeltaWindow int
+}
+
+func (f *featureConfig) mfccOptions() *mfcc.Options {
+	res := &mfcc.Options{
+		Window:      f.Window,
+		Overlap:     f.Overlap,
+		FFTSize:     f.FFTSize,
+		MelCount:    f.MelCount,
+		KeepCount:   f.KeepCount,
+		PreEmphasis: f.PreEmphasis,
+		Lifter:      f.Lifter,
+	}
+	if f.Hamming {
+		res.WindowFunc = mfcc.HammingWindow
+	}
+	return res
+}
+
+// statsFile is the saved output: the statistics, plus
+// the configuration, which mfcc.LoadCMVNStats ignores.
+type statsFile struct {
+	*mfcc.CMVNStats
+	Features featureConfig
+}
+
+func main() {
+	var config featureConfig
+	flag.DurationVar(&config.Window, "window", 0, "MFCC window duration (0 for default)")
+	flag.DurationVar(&config.Overlap, "overlap", 0, "MFCC window overlap (0 for default)")
+	flag.IntVar(&config.FFTSize, "fftsize", 0, "FFT size (0 for default)")
+	flag.IntVar(&config.MelCount, "melcount", 0, "number of Mel banks (0 for default)")
+	flag.IntVar(&config.KeepCount, "keepcount", 0, "number of MFCCs to keep (0 for default)")
+	flag.Float64Var(&config.PreEmphasis, "preemphasis", 0, "pre-emphasis coefficient")
+	flag.Float64Var(&config.Lifter, "lifter", 0, "cepstral lifter parameter")
+	flag.BoolVar(&config.Hamming, "hamming", false, "apply a Hamming window to each frame")
+	flag.IntVar(&config.Deltas, "deltas", 0, "order of deltas to include in the features")
+	flag.IntVar(&config.DeltaWindow, "deltawindow", 2, "window size for computing deltas")
+
+	flag.Parse()
+
+	if len(flag.Args()) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: cmvn-stats [flags] data_dir output.json\n\n"+
+			"Available flags:")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+
+	index, err := speechdata.LoadIndex(flag.Args()[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load index:", err)
+		os.Exit(1)
+	}
+
+	var stats mfcc.CMVNStats
+	for _, sample := range index.Samples {
+		if sample.File == "" {
+			continue
+		}
+		path := filepath.Join(index.DirPath, sample.File)
+		if err := addFileStats(&stats, path, &config); err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to process "+sample.ID+":", err)
+			os.Exit(1)
+		}
+	}
+
+	data, err := json.Marshal(&statsFile{CMVNStats: &stats, Features: config})
+	if err == nil {
+		err = ioutil.WriteFile(flag.Args()[1], data, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to save statistics:", err)
+		os.Exit(1)
+	}
+}
+
+func addFileStats(stats *mfcc.CMVNStats, path string, config *featureConfig) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	reader, err := wavio.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return err
+	}
+	channel := mfcc.SelectChannel(reader, reader.Channels(), 0)
+
+	source := mfcc.MFCC(channel, reader.SampleRate(), config.mfccOptions())
+	if config.Deltas > 0 {
+		source = mfcc.AddDeltas(source, config.DeltaWindow, config.Deltas)
+	}
+	return stats.AddAll(source)
+}
@@ -0,0 +1,141 @@
+// Command feat-export computes MFCCs for the recordings
+// in a speech data directory and saves them in a format
+// which other speech toolkits can read.
+//
+// In the Kaldi format, the output is a prefix: features
+// are written to output.ark, indexed by output.scp.
+// In the HTK format, the output is a directory with one
+// parameter file per sample.
+// HTK files use the USER parameter kind, since the
+// coefficient layout differs from HTK's own MFCCs.
+//
+// The features can be loaded back with package featio.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/unixpickle/speechrecog/featio"
+	"github.com/unixpickle/speechrecog/mfcc"
+	"github.com/unixpickle/speechrecog/speechdata"
+	"github.com/unixpickle/speechrecog/wavio"
+)
+
+func main() {
+	var format string
+	var deltaOrder int
+	var deltaWindow int
+	flag.StringVar(&format, "format", "kaldi", "output format (kaldi or htk)")
+	flag.IntVar(&deltaOrder, "deltas", 0, "order of deltas to include in the features")
+	flag.IntVar(&deltaWindow, "deltawindow", 2, "window size for computing deltas")
+
+	flag.Parse()
+
+	if len(flag.Args()) != 2 || (format != "kaldi" && format != "htk") {
+		fmt.Fprintln(os.Stderr, "Usage: feat-export [flags] data_dir output\n\n"+
+			"Available flags:")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+
+	index, err := speechdata.LoadIndex(flag.Args()[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load index:", err)
+		os.Exit(1)
+	}
+
+	output := flag.Args()[1]
+	var kaldi *featio.KaldiWriter
+	if format == "kaldi" {
+		kaldi, err = featio.CreateKaldi(output+".ark", output+".scp")
+	} else {
+		err = os.MkdirAll(output, 0755)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to create output:", err)
+		os.Exit(1)
+	}
+
+	for _, sample := range index.Samples {
+		if sample.File == "" {
+			continue
+		}
+		path := filepath.Join(index.DirPath, sample.File)
+		if err := exportSample(kaldi, output, sample.ID, path, deltaOrder,
+			deltaWindow); err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to process "+sample.ID+":", err)
+			os.Exit(1)
+		}
+	}
+
+	if kaldi != nil {
+		if err := kaldi.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to save features:", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func exportSample(kaldi *featio.KaldiWriter, output, id, path string, deltaOrder,
+	deltaWindow int) error {
+	var htkPath string
+	if kaldi == nil {
+		var err error
+		htkPath, err = featio.HTKPath(output, id)
+		if err != nil {
+			return err
+		}
+	}
+	features, period, err := fileFeatures(path, deltaOrder, deltaWindow)
+	if err != nil {
+		return err
+	}
+	if kaldi != nil {
+		return kaldi.Write(id, features)
+	}
+	return featio.WriteHTKFile(htkPath, &featio.HTKFile{
+		Features:     features,
+		SamplePeriod: period,
+		Kind:         featio.HTKUser,
+	})
+}
+
+func fileFeatures(path string, deltaOrder, deltaWindow int) ([][]float64,
+	time.Duration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	reader, err := wavio.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, 0, err
+	}
+	channel := mfcc.SelectChannel(reader, reader.Channels(), 0)
+
+	options := &mfcc.Options{}
+	layout := mfcc.NewFrameLayout(reader.SampleRate(), options)
+	period := layout.SampleTime(layout.Step)
+
+	source := mfcc.MFCC(channel, reader.SampleRate(), options)
+	if deltaOrder > 0 {
+		source = mfcc.AddDeltas(source, deltaWindow, deltaOrder)
+	}
+	var features [][]float64
+	for {
+		coeffs, err := source.NextCoeffs()
+		if err == io.EOF {
+			return features, period, nil
+		} else if err != nil {
+			return nil, 0, err
+		}
+		features = append(features, coeffs)
+	}
+}
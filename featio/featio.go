@@ -0,0 +1,36 @@
+// Package featio reads and writes feature files in the
+// formats used by other speech toolkits, so that
+// features can be computed once and shared.
+//
+// Kaldi archives (.ark) and script files (.scp) store
+// many utterances, keyed by ID.
+// HTK parameter files store one utterance each, so a
+// directory of them is keyed by file name.
+package featio
+
+import "fmt"
+
+// maxFeatureSize limits the size of the feature vectors
+// which can be read, so that a corrupt header cannot
+// force a huge allocation.
+const maxFeatureSize = 1 << 16
+
+// An Entry is the matrix of feature vectors for one
+// utterance, such as a speechdata.Sample.
+type Entry struct {
+	ID       string
+	Features [][]float64
+}
+
+// checkMatrixSize validates the dimensions of a feature
+// matrix before it is read or written.
+//
+// Rows without columns are rejected, since a corrupt
+// header could otherwise claim billions of them without
+// any data to back them.
+func checkMatrixSize(rows, cols int) error {
+	if rows < 0 || cols < 0 || cols > maxFeatureSize || (rows > 0 && cols == 0) {
+		return fmt.Errorf("invalid feature matrix size %dx%d", rows, cols)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package featio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTKExtension is the file extension used for HTK
+// parameter files in a directory of features.
+const HTKExtension = ".htk"
+
+// htkPeriodUnit is the unit of HTK sample periods.
+const htkPeriodUnit = 100 * time.Nanosecond
+
+// A ParmKind is an HTK parameter kind: a basic kind
+// such as HTKMFCC, combined with qualifiers such as
+// HTKDelta.
+type ParmKind uint16
+
+// Basic parameter kinds.
+const (
+	HTKWaveform  ParmKind = 0
+	HTKLPC       ParmKind = 1
+	HTKLPCepstra ParmKind = 3
+	HTKMFCC      ParmKind = 6
+	HTKFBank     ParmKind = 7
+	HTKMelSpec   ParmKind = 8
+	HTKUser      ParmKind = 9
+	HTKPLP       ParmKind = 11
+)
+
+// Parameter kind qualifiers.
+const (
+	HTKEnergy     ParmKind = 0100
+	HTKNoEnergy   ParmKind = 0200
+	HTKDelta      ParmKind = 0400
+	HTKAccel      ParmKind = 01000
+	HTKCompressed ParmKind = 02000
+	HTKZeroMean   ParmKind = 04000
+	HTKChecksum   ParmKind = 010000
+	HTKC0         ParmKind = 020000
+)
+
+// Base returns the basic kind, without qualifiers.
+func (p ParmKind) Base() ParmKind {
+	return p & 077
+}
+
+// An HTKFile is the contents of an HTK parameter file.
+type HTKFile struct {
+	Features     [][]float64
+	SamplePeriod time.Duration
+	Kind         ParmKind
+}
+
+// ReadHTK decodes an HTK parameter file.
+//
+// Compressed files and files with checksums are not
+// supported.
+func ReadHTK(r io.Reader) (*HTKFile, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	count := int32(binary.BigEndian.Uint32(header[0:]))
+	period := int32(binary.BigEndian.Uint32(header[4:]))
+	size := int16(binary.BigEndian.Uint16(header[8:]))
+	kind := ParmKind(binary.BigEndian.Uint16(header[10:]))
+
+	if kind&(HTKCompressed|HTKChecksum) != 0 {
+		return nil, errors.New("compressed HTK files are not supported")
+	} else if kind.Base() == HTKWaveform {
+		return nil, errors.New("HTK waveform files are not supported")
+	} else if size%4 != 0 {
+		return nil, errors.New("invalid HTK sample size")
+	} else if err := checkMatrixSize(int(count), int(size)/4); err != nil {
+		return nil, err
+	}
+
+	res := &HTKFile{
+		SamplePeriod: time.Duration(period) * htkPeriodUnit,
+		Kind:         kind,
+	}
+
+	// Rows are appended as they are read, so that memory
+	// is only used for data which is actually present.
+	row := make([]byte, size)
+	for i := 0; i < int(count); i++ {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		vec := make([]float64, len(row)/4)
+		for j := range vec {
+			vec[j] = float64(math.Float32frombits(binary.BigEndian.Uint32(row[j*4:])))
+		}
+		res.Features = append(res.Features, vec)
+	}
+	return res, nil
+}
+
+// ReadHTKFile reads an HTK parameter file from disk.
+func ReadHTKFile(path string) (*HTKFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadHTK(bufio.NewReader(f))
+}
+
+// Write encodes the file with 32-bit float parameters.
+func (h *HTKFile) Write(w io.Writer) error {
+	if h.Kind&(HTKCompressed|HTKChecksum) != 0 {
+		return errors.New("compressed HTK files are not supported")
+	} else if h.Kind.Base() == HTKWaveform {
+		return errors.New("HTK waveform files are not supported")
+	}
+	var cols int
+	if len(h.Features) > 0 {
+		cols = len(h.Features[0])
+	}
+	for _, vec := range h.Features {
+		if len(vec) != cols {
+			return errors.New("feature vectors have different sizes")
+		}
+	}
+	if err := checkMatrixSize(len(h.Features), cols); err != nil {
+		return err
+	}
+	period := h.SamplePeriod / htkPeriodUnit
+	if len(h.Features) > math.MaxInt32 || cols*4 > math.MaxInt16 ||
+		period > math.MaxInt32 {
+		return errors.New("features too large for HTK file")
+	}
+
+	var header [12]byte
+	binary.BigEndian.PutUint32(header[0:], uint32(len(h.Features)))
+	binary.BigEndian.PutUint32(header[4:], uint32(period))
+	binary.BigEndian.PutUint16(header[8:], uint16(cols*4))
+	binary.BigEndian.PutUint16(header[10:], uint16(h.Kind))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+
+	row := make([]byte, cols*4)
+	for _, vec := range h.Features {
+		for j, x := range vec {
+			binary.BigEndian.PutUint32(row[j*4:], math.Float32bits(float32(x)))
+		}
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteHTKFile writes an HTK parameter file to disk.
+func WriteHTKFile(path string, h *HTKFile) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := h.Write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// HTKPath returns the path of an entry's file in a
+// directory of HTK features.
+//
+// It returns an error if the ID is empty or contains a
+// path separator, since the file would not be directly
+// inside the directory.
+func HTKPath(dir, id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return "", errors.New("invalid HTK file ID: " + strconv.Quote(id))
+	}
+	return filepath.Join(dir, id+HTKExtension), nil
+}
+
+// WriteHTKDir writes one HTK parameter file per entry
+// into a directory, which must already exist.
+// Each file is named after its entry's ID.
+func WriteHTKDir(dir string, entries []Entry, period time.Duration,
+	kind ParmKind) error {
+	for _, entry := range entries {
+		path, err := HTKPath(dir, entry.ID)
+		if err != nil {
+			return err
+		}
+		file := &HTKFile{
+			Features:     entry.Features,
+			SamplePeriod: period,
+			Kind:         kind,
+		}
+		if err := WriteHTKFile(path, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadHTKDir reads every HTK parameter file in a
+// directory, using the file names as IDs.
+// The entries are sorted by ID.
+func ReadHTKDir(dir string) ([]Entry, error) {
+	listing, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, info := range listing {
+		name := info.Name()
+		if !info.IsDir() && strings.HasSuffix(name, HTKExtension) {
+			ids = append(ids, strings.TrimSuffix(name, HTKExtension))
+		}
+	}
+	sort.Strings(ids)
+
+	res := make([]Entry, len(ids))
+	for i, id := range ids {
+		file, err := ReadHTKFile(filepath.Join(dir, id+HTKExtension))
+		if err != nil {
+			return nil, err
+		}
+		res[i] = Entry{ID: id, Features: file.Features}
+	}
+	return res, nil
+}
@@ -0,0 +1,101 @@
+package featio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHTKRoundTrip(t *testing.T) {
+	file := &HTKFile{
+		Features:     [][]float64{{1, 2}, {3, -4.25}, {0.1, 0}},
+		SamplePeriod: 10 * time.Millisecond,
+		Kind:         HTKMFCC | HTKDelta | HTKC0,
+	}
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	header := []byte{0, 0, 0, 3, 0, 1, 0x86, 0xa0, 0, 8, 0x21, 0x06}
+	if !bytes.Equal(buf.Bytes()[:12], header) {
+		t.Errorf("expected header %v but got %v", header, buf.Bytes()[:12])
+	}
+	if buf.Len() != 12+3*2*4 {
+		t.Errorf("unexpected file size %d", buf.Len())
+	}
+
+	actual, err := ReadHTK(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.SamplePeriod != file.SamplePeriod || actual.Kind != file.Kind {
+		t.Errorf("expected period %v and kind %o but got %v and %o", file.SamplePeriod,
+			file.Kind, actual.SamplePeriod, actual.Kind)
+	}
+	if actual.Kind.Base() != HTKMFCC {
+		t.Errorf("unexpected base kind %d", actual.Kind.Base())
+	}
+	expected := []Entry{{Features: file.Features}}
+	if !entriesEqual([]Entry{{Features: actual.Features}}, expected, true) {
+		t.Errorf("expected %v but got %v", file.Features, actual.Features)
+	}
+}
+
+func TestHTKCorruptSize(t *testing.T) {
+	header := []byte{0x7f, 0xff, 0xff, 0xff, 0, 1, 0x86, 0xa0, 0, 8, 0, 9}
+	if _, err := ReadHTK(bytes.NewReader(header)); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF but got %v", err)
+	}
+	header[9] = 0
+	if _, err := ReadHTK(bytes.NewReader(header)); err == nil || err == io.ErrUnexpectedEOF {
+		t.Errorf("expected size error but got %v", err)
+	}
+}
+
+func TestHTKCompressed(t *testing.T) {
+	header := []byte{0, 0, 0, 1, 0, 1, 0x86, 0xa0, 0, 4, 0x04, 0x06}
+	if _, err := ReadHTK(bytes.NewReader(header)); err == nil {
+		t.Error("expected error for compressed file")
+	}
+	file := &HTKFile{Kind: HTKMFCC | HTKCompressed}
+	if err := file.Write(ioutil.Discard); err == nil {
+		t.Error("expected error when writing compressed file")
+	}
+}
+
+func TestHTKDirRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "featio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	entries := testEntries()
+	if err := WriteHTKDir(dir, entries, 10*time.Millisecond, HTKUser); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ReadHTKDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ReadHTKDir sorts by ID.
+	expected := []Entry{entries[0], entries[2], entries[1]}
+	if !entriesEqual(actual, expected, true) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestHTKPath(t *testing.T) {
+	for _, id := range []string{"", "a/b", "../x", `a\b`} {
+		if _, err := HTKPath("dir", id); err == nil {
+			t.Errorf("expected error for ID %q", id)
+		}
+	}
+	err := WriteHTKDir(os.TempDir(), []Entry{{ID: "../escape"}}, time.Millisecond, HTKUser)
+	if err == nil {
+		t.Error("expected error when writing an invalid ID")
+	}
+}
@@ -0,0 +1,363 @@
+package featio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Tokens for Kaldi matrices of 32-bit and 64-bit floats.
+const (
+	kaldiFloatMatrix  = "FM"
+	kaldiDoubleMatrix = "DM"
+)
+
+// kaldiBinaryMarker starts every object in a binary
+// Kaldi archive.
+const kaldiBinaryMarker = "\x00B"
+
+// An ArkWriter writes matrices to a binary Kaldi
+// archive.
+type ArkWriter struct {
+	w      io.Writer
+	offset int64
+
+	// Double can be set to store 64-bit floats ("DM")
+	// instead of 32-bit floats ("FM").
+	Double bool
+}
+
+// NewArkWriter creates an ArkWriter which writes to w,
+// starting at offset 0.
+func NewArkWriter(w io.Writer) *ArkWriter {
+	return &ArkWriter{w: w}
+}
+
+// Write adds a matrix to the archive.
+//
+// It returns the offset of the matrix in the archive,
+// which is what a script file refers to.
+func (a *ArkWriter) Write(id string, features [][]float64) (offset int64, err error) {
+	if id == "" || strings.ContainsAny(id, " \t\n") {
+		return 0, errors.New("invalid Kaldi key: " + strconv.Quote(id))
+	}
+	var cols int
+	if len(features) > 0 {
+		cols = len(features[0])
+	}
+	for _, vec := range features {
+		if len(vec) != cols {
+			return 0, errors.New("feature vectors have different sizes")
+		}
+	}
+	if err := checkMatrixSize(len(features), cols); err != nil {
+		return 0, err
+	}
+
+	buf := []byte(id + " ")
+	offset = a.offset + int64(len(buf))
+	buf = append(buf, kaldiBinaryMarker...)
+	elemSize := 4
+	if a.Double {
+		buf = append(buf, kaldiDoubleMatrix+" "...)
+		elemSize = 8
+	} else {
+		buf = append(buf, kaldiFloatMatrix+" "...)
+	}
+	buf = appendKaldiInt(buf, len(features))
+	buf = appendKaldiInt(buf, cols)
+
+	data := make([]byte, len(features)*cols*elemSize)
+	for i, vec := range features {
+		for j, x := range vec {
+			idx := (i*cols + j) * elemSize
+			if a.Double {
+				binary.LittleEndian.PutUint64(data[idx:], math.Float64bits(x))
+			} else {
+				binary.LittleEndian.PutUint32(data[idx:], math.Float32bits(float32(x)))
+			}
+		}
+	}
+	buf = append(buf, data...)
+
+	n, err := a.w.Write(buf)
+	a.offset += int64(n)
+	return offset, err
+}
+
+func appendKaldiInt(buf []byte, x int) []byte {
+	var b [5]byte
+	b[0] = 4
+	binary.LittleEndian.PutUint32(b[1:], uint32(int32(x)))
+	return append(buf, b[:]...)
+}
+
+// An ArkReader reads matrices from a binary Kaldi
+// archive, one at a time.
+type ArkReader struct {
+	r *bufio.Reader
+}
+
+// NewArkReader creates an ArkReader which reads from r.
+func NewArkReader(r io.Reader) *ArkReader {
+	return &ArkReader{r: bufio.NewReader(r)}
+}
+
+// Next reads the next matrix.
+// It returns io.EOF at the end of the archive.
+//
+// Only binary float and double matrices are supported;
+// text and compressed matrices result in an error.
+func (a *ArkReader) Next() (*Entry, error) {
+	id, err := a.r.ReadString(' ')
+	if err == io.EOF && strings.TrimSpace(id) == "" {
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	id = strings.TrimLeft(id[:len(id)-1], " \t\n")
+	features, err := readKaldiMatrix(a.r)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{ID: id, Features: features}, nil
+}
+
+// ReadKaldiArk reads every matrix from a binary Kaldi
+// archive file.
+func ReadKaldiArk(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := NewArkReader(f)
+	var res []Entry
+	for {
+		entry, err := r.Next()
+		if err == io.EOF {
+			return res, nil
+		} else if err != nil {
+			return nil, err
+		}
+		res = append(res, *entry)
+	}
+}
+
+// ReadKaldiScp reads the matrices listed in a Kaldi
+// script file.
+//
+// Each line of the file has an ID and a file name,
+// optionally followed by a colon and the offset of the
+// matrix in the file, as in "utt1 feats.ark:5".
+// As in Kaldi, relative file names are relative to the
+// working directory, not to the script file.
+func ReadKaldiScp(path string) ([]Entry, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*os.File{}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	var res []Entry
+	for lineIdx, line := range strings.Split(string(contents), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		} else if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected ID and file name", path, lineIdx+1)
+		}
+		name, offset := fields[1], int64(0)
+		if idx := strings.LastIndex(name, ":"); idx >= 0 {
+			offset, err = strconv.ParseInt(name[idx+1:], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid offset", path, lineIdx+1)
+			}
+			name = name[:idx]
+		}
+
+		f, ok := files[name]
+		if !ok {
+			f, err = os.Open(name)
+			if err != nil {
+				return nil, err
+			}
+			files[name] = f
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		features, err := readKaldiMatrix(bufio.NewReader(f))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, lineIdx+1, err)
+		}
+		res = append(res, Entry{ID: fields[0], Features: features})
+	}
+	return res, nil
+}
+
+// A KaldiWriter writes a binary Kaldi archive along with
+// a script file which indexes it.
+type KaldiWriter struct {
+	arkPath string
+
+	arkFile *os.File
+	arkBuf  *bufio.Writer
+	ark     *ArkWriter
+	scpFile *os.File
+	scpBuf  *bufio.Writer
+}
+
+// CreateKaldi creates an archive and a script file.
+// The script file refers to the archive by arkPath, so
+// a relative path is only valid from the current working
+// directory.
+func CreateKaldi(arkPath, scpPath string) (*KaldiWriter, error) {
+	arkFile, err := os.Create(arkPath)
+	if err != nil {
+		return nil, err
+	}
+	scpFile, err := os.Create(scpPath)
+	if err != nil {
+		arkFile.Close()
+		return nil, err
+	}
+	arkBuf := bufio.NewWriter(arkFile)
+	return &KaldiWriter{
+		arkPath: arkPath,
+		arkFile: arkFile,
+		arkBuf:  arkBuf,
+		ark:     NewArkWriter(arkBuf),
+		scpFile: scpFile,
+		scpBuf:  bufio.NewWriter(scpFile),
+	}, nil
+}
+
+// Write adds a matrix to the archive and the script
+// file.
+func (k *KaldiWriter) Write(id string, features [][]float64) error {
+	offset, err := k.ark.Write(id, features)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(k.scpBuf, "%s %s:%d\n", id, k.arkPath, offset)
+	return err
+}
+
+// Close flushes and closes both files.
+func (k *KaldiWriter) Close() error {
+	var firstErr error
+	for _, err := range []error{
+		k.arkBuf.Flush(),
+		k.arkFile.Close(),
+		k.scpBuf.Flush(),
+		k.scpFile.Close(),
+	} {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WriteKaldi writes entries to a binary Kaldi archive
+// and a script file, as with CreateKaldi.
+func WriteKaldi(arkPath, scpPath string, entries []Entry) error {
+	w, err := CreateKaldi(arkPath, scpPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := w.Write(entry.ID, entry.Features); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+func readKaldiMatrix(r *bufio.Reader) ([][]float64, error) {
+	var marker [2]byte
+	if _, err := io.ReadFull(r, marker[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if string(marker[:]) != kaldiBinaryMarker {
+		return nil, errors.New("only binary Kaldi matrices are supported")
+	}
+	token, err := r.ReadString(' ')
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	var elemSize int
+	switch token[:len(token)-1] {
+	case kaldiFloatMatrix:
+		elemSize = 4
+	case kaldiDoubleMatrix:
+		elemSize = 8
+	default:
+		return nil, errors.New("unsupported Kaldi object: " + strconv.Quote(token))
+	}
+
+	rows, err := readKaldiInt(r)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := readKaldiInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMatrixSize(rows, cols); err != nil {
+		return nil, err
+	}
+
+	// Rows are appended as they are read, so that memory
+	// is only used for data which is actually present.
+	var res [][]float64
+	row := make([]byte, cols*elemSize)
+	for i := 0; i < rows; i++ {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		vec := make([]float64, cols)
+		for j := range vec {
+			if elemSize == 4 {
+				bits := binary.LittleEndian.Uint32(row[j*4:])
+				vec[j] = float64(math.Float32frombits(bits))
+			} else {
+				vec[j] = math.Float64frombits(binary.LittleEndian.Uint64(row[j*8:]))
+			}
+		}
+		res = append(res, vec)
+	}
+	return res, nil
+}
+
+func readKaldiInt(r *bufio.Reader) (int, error) {
+	var b [5]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	if b[0] != 4 {
+		return 0, errors.New("unsupported Kaldi integer size")
+	}
+	return int(int32(binary.LittleEndian.Uint32(b[1:]))), nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package featio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArkBinaryLayout(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewArkWriter(&buf)
+	offset, err := w.Write("utt1", [][]float64{{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != 5 {
+		t.Errorf("expected offset 5 but got %d", offset)
+	}
+	expected := []byte("utt1 \x00BFM \x04\x01\x00\x00\x00\x04\x02\x00\x00\x00" +
+		"\x00\x00\x80\x3f\x00\x00\x00\x40")
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("expected %q but got %q", expected, buf.Bytes())
+	}
+
+	offset, err = w.Write("utt2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != int64(len(expected)+5) {
+		t.Errorf("expected offset %d but got %d", len(expected)+5, offset)
+	}
+
+	if _, err := w.Write("bad key", nil); err == nil {
+		t.Error("expected error for key with a space")
+	}
+	if _, err := w.Write("ragged", [][]float64{{1}, {2, 3}}); err == nil {
+		t.Error("expected error for ragged matrix")
+	}
+}
+
+func TestArkRoundTrip(t *testing.T) {
+	entries := testEntries()
+	for _, double := range []bool{false, true} {
+		var buf bytes.Buffer
+		w := NewArkWriter(&buf)
+		w.Double = double
+		for _, entry := range entries {
+			if _, err := w.Write(entry.ID, entry.Features); err != nil {
+				t.Fatal(err)
+			}
+		}
+		r := NewArkReader(&buf)
+		var actual []Entry
+		for {
+			entry, err := r.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			actual = append(actual, *entry)
+		}
+		if !entriesEqual(actual, entries, !double) {
+			t.Errorf("double=%v: expected %v but got %v", double, entries, actual)
+		}
+	}
+}
+
+func TestArkTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	NewArkWriter(&buf).Write("utt1", [][]float64{{1, 2, 3}})
+	data := buf.Bytes()
+	for _, size := range []int{3, 6, 12, len(data) - 1} {
+		r := NewArkReader(bytes.NewReader(data[:size]))
+		if _, err := r.Next(); err != io.ErrUnexpectedEOF {
+			t.Errorf("size %d: expected io.ErrUnexpectedEOF but got %v", size, err)
+		}
+	}
+}
+
+func TestArkCorruptSize(t *testing.T) {
+	header := func(rows, cols uint32) []byte {
+		buf := []byte("utt1 \x00BFM \x04")
+		buf = append(buf, byte(rows), byte(rows>>8), byte(rows>>16), byte(rows>>24), 4)
+		return append(buf, byte(cols), byte(cols>>8), byte(cols>>16), byte(cols>>24))
+	}
+	r := NewArkReader(bytes.NewReader(header(0x7fffffff, 2)))
+	if _, err := r.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF but got %v", err)
+	}
+	for _, size := range [][2]uint32{{1, 0x7fffffff}, {0x7fffffff, 0}, {1, 0xffffffff}} {
+		r := NewArkReader(bytes.NewReader(header(size[0], size[1])))
+		if _, err := r.Next(); err == nil || err == io.ErrUnexpectedEOF {
+			t.Errorf("size %v: expected size error but got %v", size, err)
+		}
+	}
+}
+
+func TestKaldiScpRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "featio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	arkPath := filepath.Join(dir, "feats.ark")
+	scpPath := filepath.Join(dir, "feats.scp")
+	entries := testEntries()
+	if err := WriteKaldi(arkPath, scpPath, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	fromScp, err := ReadKaldiScp(scpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !entriesEqual(fromScp, entries, true) {
+		t.Errorf("scp: expected %v but got %v", entries, fromScp)
+	}
+	fromArk, err := ReadKaldiArk(arkPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !entriesEqual(fromArk, entries, true) {
+		t.Errorf("ark: expected %v but got %v", entries, fromArk)
+	}
+}
+
+func testEntries() []Entry {
+	return []Entry{
+		{ID: "a", Features: [][]float64{{1, 2, 3}, {-4, 5.5, 1e-3}}},
+		{ID: "empty"},
+		{ID: "c", Features: [][]float64{{math.Pi}}},
+	}
+}
+
+func entriesEqual(actual, expected []Entry, single bool) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+	for i, entry := range expected {
+		if actual[i].ID != entry.ID || len(actual[i].Features) != len(entry.Features) {
+			return false
+		}
+		for j, vec := range entry.Features {
+			if len(actual[i].Features[j]) != len(vec) {
+				return false
+			}
+			for k, x := range vec {
+				if single {
+					x = float64(float32(x))
+				}
+				if actual[i].Features[j][k] != x {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
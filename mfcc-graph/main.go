@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"strconv"
 	"time"
@@ -26,59 +27,79 @@ var CepstrumColors = []string{
 }
 
 func main() {
-	if len(os.Args) != 3 && len(os.Args) != 4 {
-		fmt.Fprintln(os.Stderr, "Usage: mfcc-graph <sound.wav> <output.html> [--velocity]")
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "Usage: mfcc-graph <sound.wav> <output.html> [--velocity]"+
+			" [--invert <inverted.wav>]")
 		os.Exit(1)
 	}
 
 	var getVelocity bool
-	if len(os.Args) == 4 {
-		if os.Args[3] != "--velocity" {
-			fmt.Fprintln(os.Stderr, "Unexpected argument:", os.Args[3])
+	var invertPath string
+	for i := 3; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--velocity":
+			getVelocity = true
+		case "--invert":
+			if i+1 == len(os.Args) {
+				fmt.Fprintln(os.Stderr, "Missing argument for --invert")
+				os.Exit(1)
+			}
+			i++
+			invertPath = os.Args[i]
+		default:
+			fmt.Fprintln(os.Stderr, "Unexpected argument:", os.Args[i])
 			os.Exit(1)
 		}
-		getVelocity = true
 	}
 
-	coeffs, err := readCoeffs(os.Args[1], getVelocity)
+	coeffs, velocities, sampleRate, err := readCoeffs(os.Args[1], getVelocity)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Failed to read MFCCs:", err)
 		os.Exit(1)
 	}
 
-	page := createHTML(createSVG(coeffs))
+	graphed := coeffs
+	if getVelocity {
+		graphed = velocities
+	}
+	page := createHTML(createSVG(graphed))
 
 	if err := ioutil.WriteFile(os.Args[2], page, OutputPerms); err != nil {
 		fmt.Fprintln(os.Stderr, "Failed to write result:", err)
 		os.Exit(1)
 	}
-}
-
-func readCoeffs(file string, velocity bool) ([][]float64, error) {
-	sound, err := wav.ReadSoundFile(os.Args[1])
-	if err != nil {
-		return nil, err
-	}
 
-	var audioData []float64
-	for i, x := range sound.Samples() {
-		if i%sound.Channels() == 0 {
-			audioData = append(audioData, float64(x))
+	if invertPath != "" {
+		if err := writeInversion(coeffs, sampleRate, invertPath); err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to write inverted audio:", err)
+			os.Exit(1)
 		}
 	}
+}
+
+func analysisOptions() *mfcc.Options {
+	return &mfcc.Options{Window: time.Millisecond * 20, Overlap: time.Millisecond * 10}
+}
 
-	mfccSource := mfcc.MFCC(&mfcc.SliceSource{Slice: audioData}, sound.SampleRate(),
-		&mfcc.Options{Window: time.Millisecond * 20, Overlap: time.Millisecond * 10})
+// readCoeffs computes the MFCCs of a sound file and, if
+// requested, their velocities.
+func readCoeffs(file string, velocity bool) (coeffs, velocities [][]float64,
+	sampleRate int, err error) {
+	sound, err := wav.ReadSoundFile(file)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	mfccSource := mfcc.MFCC(firstChannel(sound), sound.SampleRate(), analysisOptions())
 	if velocity {
 		mfccSource = mfcc.AddVelocities(mfccSource)
 	}
 
-	var coeffs [][]float64
 	for {
 		c, err := mfccSource.NextCoeffs()
 		if err == nil {
 			if velocity {
-				coeffs = append(coeffs, c[len(c)/2:])
+				coeffs = append(coeffs, c[:len(c)/2])
+				velocities = append(velocities, c[len(c)/2:])
 			} else {
 				coeffs = append(coeffs, c)
 			}
@@ -87,7 +108,41 @@ func readCoeffs(file string, velocity bool) ([][]float64, error) {
 		}
 	}
 
-	return coeffs, nil
+	return coeffs, velocities, sound.SampleRate(), nil
+}
+
+// writeInversion reconstructs audio from MFCCs computed
+// with analysisOptions, so that one can hear what they
+// capture.
+func writeInversion(coeffs [][]float64, sampleRate int, output string) error {
+	audio := mfcc.InvertMFCC(coeffs, sampleRate, analysisOptions(), &mfcc.InvertOptions{
+		Resampling: mfcc.MediumQuality,
+	})
+
+	// The reconstruction's overall gain is arbitrary, so
+	// it is normalized to avoid clipping.
+	var peak float64
+	for _, x := range audio {
+		peak = math.Max(peak, math.Abs(x))
+	}
+	samples := make([]wav.Sample, len(audio))
+	for i, x := range audio {
+		if peak > 0 {
+			x *= 0.9 / peak
+		}
+		samples[i] = wav.Sample(x)
+	}
+	result := wav.NewPCM16Sound(1, sampleRate)
+	result.SetSamples(samples)
+	return wav.WriteFile(result, output)
+}
+
+func firstChannel(sound wav.Sound) mfcc.Source {
+	samples := make([]float64, len(sound.Samples()))
+	for i, x := range sound.Samples() {
+		samples[i] = float64(x)
+	}
+	return mfcc.SelectChannel(&mfcc.SliceSource{Slice: samples}, sound.Channels(), 0)
 }
 
 func createSVG(coeffs [][]float64) []byte {
@@ -0,0 +1,74 @@
+package mfcc
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/unixpickle/num-analysis/linalg"
+)
+
+// ComputeAll computes the MFCCs of a complete recording.
+//
+// The result is exactly the same as reading every vector
+// from MFCC with a SliceSource of the samples.
+func ComputeAll(samples []float64, sampleRate int, options *Options) [][]float64 {
+	return computeFrames(samples, sampleRate, options, 1)
+}
+
+// ComputeAllParallel is like ComputeAll, but it divides
+// the frames between up to maxProcs goroutines, and it
+// returns vectors which can be used directly as the
+// input of a ctc.Sample.
+//
+// If maxProcs is 0, runtime.GOMAXPROCS(0) is used.
+//
+// Resampling and pre-emphasis are performed up front on
+// a single goroutine, and the results are exactly the
+// same as those of ComputeAll.
+func ComputeAllParallel(samples []float64, sampleRate int, options *Options,
+	maxProcs int) []linalg.Vector {
+	if maxProcs <= 0 {
+		maxProcs = runtime.GOMAXPROCS(0)
+	}
+	vecs := computeFrames(samples, sampleRate, options, maxProcs)
+	res := make([]linalg.Vector, len(vecs))
+	for i, vec := range vecs {
+		res[i] = vec
+	}
+	return res
+}
+
+func computeFrames(samples []float64, sampleRate int, options *Options,
+	procs int) [][]float64 {
+	c := newCoeffChan(&SliceSource{Slice: samples}, sampleRate, options, cepstrumStage)
+	signal := collectSamples(c.prepared)
+
+	layout := FrameLayout{
+		SampleRate: c.sampleRate,
+		FrameSize:  c.frameSize,
+		Step:       c.step,
+		FFTSize:    c.fftSize,
+	}
+	res := make([][]float64, layout.FrameCount(len(signal)))
+	if procs > len(res) {
+		procs = len(res)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < procs; i++ {
+		wg.Add(1)
+		go func(first int) {
+			defer wg.Done()
+			buf := make([]float64, c.fftSize)
+			for idx := first; idx < len(res); idx += procs {
+				start := idx * c.step
+				have := copy(buf[:c.frameSize], signal[start:])
+				powers, energy := c.framePowers(buf, have)
+				res[idx] = c.stageOutput(powers, energy)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return res
+}
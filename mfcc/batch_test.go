@@ -0,0 +1,78 @@
+package mfcc
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestComputeAllIdentical(t *testing.T) {
+	optionList := []*Options{
+		nil,
+		{
+			PreEmphasis: 0.97,
+			RemoveDC:    true,
+			WindowFunc:  HammingWindow,
+			Resampling:  MediumQuality,
+			Energy:      AppendEnergy,
+			Lifter:      22,
+		},
+		{
+			NativeSampleRate: true,
+			Window:           25 * time.Millisecond,
+			Overlap:          15 * time.Millisecond,
+			WindowFunc:       PoveyWindow,
+			RawEnergy:        true,
+			Energy:           EnergyAsC0,
+			DCTNorm:          OrthoDCTNorm,
+		},
+	}
+	gen := rand.New(rand.NewSource(1337))
+	for i, opts := range optionList {
+		for _, count := range []int{0, 1, 100, 8000, 12345} {
+			samples := make([]float64, count)
+			for j := range samples {
+				samples[j] = gen.NormFloat64()
+			}
+
+			// The streaming path reads in small, uneven
+			// chunks to make sure that chunking does not
+			// change the results.
+			expected := readAllCoeffs(t, MFCC(&sliceSource{vec: samples, buffSize: 77},
+				16000, opts))
+
+			actual := ComputeAll(samples, 16000, opts)
+			if !coeffsIdentical(actual, expected) {
+				t.Errorf("options %d, %d samples: ComputeAll differs from MFCC", i, count)
+			}
+			for _, procs := range []int{0, 1, 3} {
+				vecs := ComputeAllParallel(samples, 16000, opts, procs)
+				parallel := make([][]float64, len(vecs))
+				for j, vec := range vecs {
+					parallel[j] = vec
+				}
+				if !coeffsIdentical(parallel, expected) {
+					t.Errorf("options %d, %d samples, %d procs: ComputeAllParallel "+
+						"differs from MFCC", i, count, procs)
+				}
+			}
+		}
+	}
+}
+
+func coeffsIdentical(actual, expected [][]float64) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+	for i, vec := range expected {
+		if len(actual[i]) != len(vec) {
+			return false
+		}
+		for j, x := range vec {
+			if actual[i][j] != x {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package mfcc
+
+import "math"
+
+// A bluesteinPlan computes discrete Fourier transforms of
+// any size using Bluestein's algorithm, which expresses
+// the transform as a convolution that can be evaluated
+// with power of 2 FFTs.
+type bluesteinPlan struct {
+	size int
+
+	// chirp[k] = exp(-i*pi*k^2/size).
+	chirp []complex128
+
+	// kernel is the transform of the conjugated chirp,
+	// wrapped around to make a circular convolution.
+	kernel []complex128
+
+	inner *FFTPlan
+}
+
+func newBluesteinPlan(size int) *bluesteinPlan {
+	m := 1
+	for m < 2*size-1 {
+		m <<= 1
+	}
+	res := &bluesteinPlan{
+		size:   size,
+		chirp:  make([]complex128, size),
+		kernel: make([]complex128, m),
+		inner:  NewFFTPlan(m),
+	}
+
+	for k := range res.chirp {
+		// Reducing k^2 mod 2*size keeps the angles
+		// accurate for large k.
+		angle := math.Pi * float64((k*k)%(2*size)) / float64(size)
+		sin, cos := math.Sincos(angle)
+		res.chirp[k] = complex(cos, -sin)
+	}
+
+	res.kernel[0] = conj(res.chirp[0])
+	for k := 1; k < size; k++ {
+		res.kernel[k] = conj(res.chirp[k])
+		res.kernel[m-k] = res.kernel[k]
+	}
+	res.inner.Transform(res.kernel, res.kernel)
+
+	return res
+}
+
+// Transform computes the transform of in, storing the
+// result in out.
+// The scratch buffer must be as large as the inner plan.
+func (b *bluesteinPlan) Transform(in, out, scratch []complex128) {
+	for k, x := range in {
+		scratch[k] = x * b.chirp[k]
+	}
+	b.convolve(scratch)
+	for k := range out {
+		out[k] = scratch[k] * b.chirp[k]
+	}
+}
+
+// TransformReal is like Transform, except that the input
+// is real and only the first len(out) outputs are
+// computed.
+func (b *bluesteinPlan) TransformReal(in []float64, out, scratch []complex128) {
+	for k, x := range in {
+		scratch[k] = complex(x*real(b.chirp[k]), x*imag(b.chirp[k]))
+	}
+	b.convolve(scratch)
+	for k := range out {
+		out[k] = scratch[k] * b.chirp[k]
+	}
+}
+
+// convolve circularly convolves the first b.size entries
+// of scratch with the kernel, zeroing the remaining
+// entries first.
+func (b *bluesteinPlan) convolve(scratch []complex128) {
+	for k := b.size; k < len(scratch); k++ {
+		scratch[k] = 0
+	}
+	b.inner.Transform(scratch, scratch)
+	for k, x := range b.kernel {
+		scratch[k] *= x
+	}
+	b.inner.Inverse(scratch, scratch)
+}
+
+func conj(c complex128) complex128 {
+	return complex(real(c), -imag(c))
+}
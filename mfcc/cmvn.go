@@ -0,0 +1,240 @@
+package mfcc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// cmvnVarianceFloor prevents division by zero when a
+// coefficient has no variance.
+const cmvnVarianceFloor = 1e-10
+
+// CMVNStats accumulates first- and second-order
+// statistics of coefficient vectors for cepstral mean and
+// variance normalization.
+type CMVNStats struct {
+	Count float64
+	Sum   []float64
+	SumSq []float64
+}
+
+// LoadCMVNStats reads statistics from a file created by
+// CMVNStats.Save.
+func LoadCMVNStats(path string) (*CMVNStats, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var res CMVNStats
+	if err := json.Unmarshal(contents, &res); err != nil {
+		return nil, err
+	}
+	if len(res.Sum) != len(res.SumSq) {
+		return nil, errors.New("mismatching CMVN statistic sizes")
+	}
+	return &res, nil
+}
+
+// Save saves the statistics to a file.
+func (c *CMVNStats) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Add adds a vector to the statistics.
+func (c *CMVNStats) Add(vec []float64) {
+	c.addScaled(vec, 1)
+}
+
+// Remove removes a vector which was previously added.
+func (c *CMVNStats) Remove(vec []float64) {
+	c.addScaled(vec, -1)
+}
+
+// AddAll adds every vector from a CoeffSource to the
+// statistics.
+// It returns the error which ended the source, or nil if
+// the source ended with io.EOF.
+func (c *CMVNStats) AddAll(source CoeffSource) error {
+	for {
+		vec, err := source.NextCoeffs()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		c.Add(vec)
+	}
+}
+
+func (c *CMVNStats) addScaled(vec []float64, scale float64) {
+	if c.Sum == nil {
+		c.Sum = make([]float64, len(vec))
+		c.SumSq = make([]float64, len(vec))
+	} else if len(vec) != len(c.Sum) {
+		panic("vector size does not match CMVN statistics")
+	}
+	c.Count += scale
+	for i, x := range vec {
+		c.Sum[i] += scale * x
+		c.SumSq[i] += scale * x * x
+	}
+}
+
+// Normalize normalizes a vector in place using the mean
+// of the statistics, and optionally their variance.
+//
+// It returns an error if the vector's size does not
+// match the statistics, such as when statistics which
+// include deltas are applied to features without them.
+func (c *CMVNStats) Normalize(vec []float64, variance bool) error {
+	if c.Count <= 0 {
+		return nil
+	}
+	if len(vec) != len(c.Sum) {
+		return fmt.Errorf("vector size %d does not match CMVN statistics size %d",
+			len(vec), len(c.Sum))
+	}
+	for i, x := range vec {
+		mean := c.Sum[i] / c.Count
+		vec[i] = x - mean
+		if variance {
+			v := c.SumSq[i]/c.Count - mean*mean
+			vec[i] /= math.Sqrt(math.Max(v, cmvnVarianceFloor))
+		}
+	}
+	return nil
+}
+
+// NormalizeUtterance generates a CoeffSource which
+// normalizes the vectors from c using the mean (and
+// optionally the variance) of all of the vectors in c.
+//
+// The first call to NextCoeffs reads all of c, so this
+// is not suitable for streaming.
+func NormalizeUtterance(c CoeffSource, variance bool) FrameSource {
+	return &utteranceCMVN{Wrapped: Frames(c), Variance: variance}
+}
+
+type utteranceCMVN struct {
+	Wrapped  FrameSource
+	Variance bool
+
+	loaded    bool
+	frames    []*Frame
+	doneError error
+}
+
+func (u *utteranceCMVN) NextCoeffs() ([]float64, error) {
+	return coeffsOf(u.NextFrame())
+}
+
+func (u *utteranceCMVN) NextFrame() (*Frame, error) {
+	if !u.loaded {
+		u.loaded = true
+		var stats CMVNStats
+		for {
+			frame, err := u.Wrapped.NextFrame()
+			if err != nil {
+				u.doneError = err
+				break
+			}
+			stats.Add(frame.Coeffs)
+			u.frames = append(u.frames, frame)
+		}
+		for i, frame := range u.frames {
+			res := append([]float64{}, frame.Coeffs...)
+			stats.Normalize(res, u.Variance)
+			u.frames[i] = frame.withCoeffs(res)
+		}
+	}
+	if len(u.frames) == 0 {
+		return nil, u.doneError
+	}
+	res := u.frames[0]
+	u.frames[0] = nil
+	u.frames = u.frames[1:]
+	return res, nil
+}
+
+// NormalizeSliding generates a CoeffSource which
+// normalizes each vector from c using the mean (and
+// optionally the variance) of the window most recent
+// vectors, including the vector being normalized.
+//
+// Since this only uses past vectors, it is suitable for
+// online, streaming use.
+func NormalizeSliding(c CoeffSource, window int, variance bool) FrameSource {
+	if window < 1 {
+		panic("CMVN window must be at least 1")
+	}
+	return &slidingCMVN{Wrapped: Frames(c), Window: window, Variance: variance}
+}
+
+type slidingCMVN struct {
+	Wrapped  FrameSource
+	Window   int
+	Variance bool
+
+	stats   CMVNStats
+	history [][]float64
+}
+
+func (s *slidingCMVN) NextCoeffs() ([]float64, error) {
+	return coeffsOf(s.NextFrame())
+}
+
+func (s *slidingCMVN) NextFrame() (*Frame, error) {
+	frame, err := s.Wrapped.NextFrame()
+	if err != nil {
+		return nil, err
+	}
+	vec := frame.Coeffs
+	s.stats.Add(vec)
+	s.history = append(s.history, vec)
+	if len(s.history) > s.Window {
+		s.stats.Remove(s.history[0])
+		s.history[0] = nil
+		s.history = s.history[1:]
+	}
+	res := append([]float64{}, vec...)
+	s.stats.Normalize(res, s.Variance)
+	return frame.withCoeffs(res), nil
+}
+
+// NormalizeGlobal generates a CoeffSource which
+// normalizes each vector from c using the mean (and
+// optionally the variance) from pre-computed statistics,
+// such as those loaded with LoadCMVNStats.
+func NormalizeGlobal(c CoeffSource, stats *CMVNStats, variance bool) FrameSource {
+	return &globalCMVN{Wrapped: Frames(c), Stats: stats, Variance: variance}
+}
+
+type globalCMVN struct {
+	Wrapped  FrameSource
+	Stats    *CMVNStats
+	Variance bool
+}
+
+func (g *globalCMVN) NextCoeffs() ([]float64, error) {
+	return coeffsOf(g.NextFrame())
+}
+
+func (g *globalCMVN) NextFrame() (*Frame, error) {
+	frame, err := g.Wrapped.NextFrame()
+	if err != nil {
+		return nil, err
+	}
+	res := append([]float64{}, frame.Coeffs...)
+	if err := g.Stats.Normalize(res, g.Variance); err != nil {
+		return nil, err
+	}
+	return frame.withCoeffs(res), nil
+}
@@ -0,0 +1,111 @@
+package mfcc
+
+import (
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeUtterance(t *testing.T) {
+	vecs := randomCMVNVecs(50)
+	actual := readAllCoeffs(t, NormalizeUtterance(&sliceCoeffSource{vecs: vecs}, true))
+	if len(actual) != 50 {
+		t.Fatalf("expected 50 vectors but got %d", len(actual))
+	}
+	if original := randomCMVNVecs(50); !coeffsIdentical(vecs, original) {
+		t.Error("input vectors were modified")
+	}
+	var stats CMVNStats
+	for _, vec := range actual {
+		stats.Add(vec)
+	}
+	for i := range stats.Sum {
+		mean := stats.Sum[i] / stats.Count
+		variance := stats.SumSq[i]/stats.Count - mean*mean
+		if i == 2 {
+			// The last coefficient is constant.
+			if math.Abs(mean) > 1e-8 || variance > 1e-8 {
+				t.Errorf("constant coefficient: mean %f variance %f", mean, variance)
+			}
+		} else if math.Abs(mean) > 1e-8 || math.Abs(variance-1) > 1e-8 {
+			t.Errorf("coefficient %d: mean %f variance %f", i, mean, variance)
+		}
+	}
+}
+
+func TestNormalizeSliding(t *testing.T) {
+	vecs := randomCMVNVecs(20)
+	source := NormalizeSliding(&sliceCoeffSource{vecs: vecs}, 5, false)
+	actual := readAllCoeffs(t, source)
+	if len(actual) != len(vecs) {
+		t.Fatalf("expected %d vectors but got %d", len(vecs), len(actual))
+	}
+	for i, vec := range actual {
+		start := maxInt(0, i-4)
+		expected := append([]float64{}, vecs[i]...)
+		for j := range expected {
+			var mean float64
+			for _, v := range vecs[start : i+1] {
+				mean += v[j]
+			}
+			expected[j] -= mean / float64(i+1-start)
+		}
+		if !slicesClose(vec, expected) {
+			t.Errorf("vector %d: expected %v got %v", i, expected, vec)
+		}
+	}
+}
+
+func TestNormalizeGlobal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmvn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	vecs := randomCMVNVecs(30)
+	var stats CMVNStats
+	if err := stats.AddAll(&sliceCoeffSource{vecs: vecs}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "stats.json")
+	if err := stats.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCMVNStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := readAllCoeffs(t, NormalizeUtterance(&sliceCoeffSource{vecs: vecs}, true))
+	actual := readAllCoeffs(t, NormalizeGlobal(&sliceCoeffSource{vecs: randomCMVNVecs(30)},
+		loaded, true))
+	for i, vec := range actual {
+		if !slicesClose(vec, expected[i]) {
+			t.Errorf("vector %d: expected %v got %v", i, expected[i], vec)
+		}
+	}
+
+	for _, size := range []int{2, 4} {
+		vec := make([]float64, size)
+		if err := loaded.Normalize(vec, true); err == nil {
+			t.Errorf("size %d: expected error", size)
+		}
+		source := NormalizeGlobal(&sliceCoeffSource{vecs: [][]float64{vec}}, loaded, true)
+		if _, err := source.NextCoeffs(); err == nil {
+			t.Errorf("size %d: expected error from NormalizeGlobal", size)
+		}
+	}
+}
+
+func randomCMVNVecs(count int) [][]float64 {
+	rand.Seed(123)
+	res := make([][]float64, count)
+	for i := range res {
+		res[i] = []float64{rand.NormFloat64()*3 + 2, rand.NormFloat64() - 5, 7}
+	}
+	return res
+}
@@ -0,0 +1,243 @@
+package mfcc
+
+import "io"
+
+// Concat generates a Source which reads each of the
+// sources in turn, moving on to the next one when the
+// current one returns io.EOF.
+// Any other error ends the resulting Source.
+func Concat(sources ...Source) Source {
+	return &concatSource{Sources: append([]Source(nil), sources...)}
+}
+
+type concatSource struct {
+	Sources []Source
+}
+
+func (c *concatSource) ReadSamples(s []float64) (n int, err error) {
+	for n < len(s) && len(c.Sources) > 0 {
+		var read int
+		read, err = c.Sources[0].ReadSamples(s[n:])
+		n += read
+		if err == io.EOF {
+			c.Sources[0] = nil
+			c.Sources = c.Sources[1:]
+			err = nil
+		} else if err != nil {
+			return
+		}
+	}
+	if len(c.Sources) == 0 {
+		err = io.EOF
+	}
+	return
+}
+
+// Mix generates a Source which adds together the samples
+// of several sources, scaling each one by the
+// corresponding gain.
+//
+// The result is as long as the longest source, with the
+// shorter sources treated as silence once they end.
+// Any error other than io.EOF ends the resulting Source.
+func Mix(sources []Source, gains []float64) Source {
+	if len(sources) != len(gains) {
+		panic("need exactly one gain per source")
+	}
+	return &mixSource{
+		Sources: sources,
+		Gains:   gains,
+		done:    make([]bool, len(sources)),
+	}
+}
+
+type mixSource struct {
+	Sources []Source
+	Gains   []float64
+
+	done      []bool
+	buffer    []float64
+	doneError error
+}
+
+func (m *mixSource) ReadSamples(s []float64) (n int, err error) {
+	if m.doneError != nil {
+		return 0, m.doneError
+	}
+	if len(m.buffer) < len(s) {
+		m.buffer = make([]float64, len(s))
+	}
+	for i := range s {
+		s[i] = 0
+	}
+
+	// Every source that hasn't ended contributes the same
+	// number of samples, so that they stay aligned.
+	for i, source := range m.Sources {
+		if m.done[i] {
+			continue
+		}
+		buf := m.buffer[:len(s)]
+		var have int
+		for have < len(buf) && !m.done[i] {
+			read, err := source.ReadSamples(buf[have:])
+			have += read
+			if err == io.EOF {
+				m.done[i] = true
+			} else if err != nil {
+				m.doneError = err
+				return 0, err
+			}
+		}
+		gain := m.Gains[i]
+		for j, x := range buf[:have] {
+			s[j] += x * gain
+		}
+		if have > n {
+			n = have
+		}
+	}
+
+	for _, done := range m.done {
+		if !done {
+			return len(s), nil
+		}
+	}
+	m.doneError = io.EOF
+	return n, io.EOF
+}
+
+// Gain generates a Source which scales the samples of a
+// Source by a constant factor.
+func Gain(s Source, gain float64) Source {
+	return &gainSource{Wrapped: s, Gain: gain}
+}
+
+type gainSource struct {
+	Wrapped Source
+	Gain    float64
+}
+
+func (g *gainSource) ReadSamples(s []float64) (n int, err error) {
+	n, err = g.Wrapped.ReadSamples(s)
+	for i := range s[:n] {
+		s[i] *= g.Gain
+	}
+	return
+}
+
+// Limit generates a Source which ends with io.EOF after
+// count samples have been read from a Source.
+func Limit(s Source, count int) Source {
+	return &limitSource{Wrapped: s, Remaining: count}
+}
+
+type limitSource struct {
+	Wrapped   Source
+	Remaining int
+}
+
+func (l *limitSource) ReadSamples(s []float64) (n int, err error) {
+	if l.Remaining <= 0 {
+		return 0, io.EOF
+	}
+	if len(s) > l.Remaining {
+		s = s[:l.Remaining]
+	}
+	n, err = l.Wrapped.ReadSamples(s)
+	l.Remaining -= n
+	if err == nil && l.Remaining == 0 {
+		err = io.EOF
+	}
+	return
+}
+
+// Skip generates a Source which discards the first count
+// samples of a Source.
+func Skip(s Source, count int) Source {
+	return &skipSource{Wrapped: s, Remaining: count}
+}
+
+type skipSource struct {
+	Wrapped   Source
+	Remaining int
+}
+
+func (k *skipSource) ReadSamples(s []float64) (n int, err error) {
+	for k.Remaining > 0 {
+		var buf [512]float64
+		chunk := buf[:minInt(len(buf), k.Remaining)]
+		read, err := k.Wrapped.ReadSamples(chunk)
+		k.Remaining -= read
+		if err != nil {
+			return 0, err
+		}
+	}
+	return k.Wrapped.ReadSamples(s)
+}
+
+// Downmix generates a Source which turns interleaved
+// samples with the given number of channels into a
+// single channel by averaging the channels.
+//
+// If the source ends in the middle of a group of
+// channels, the partial group is dropped.
+func Downmix(s Source, channels int) Source {
+	weights := make([]float64, channels)
+	for i := range weights {
+		weights[i] = 1 / float64(channels)
+	}
+	return &downmixSource{Wrapped: s, Weights: weights}
+}
+
+// SelectChannel generates a Source which produces one
+// channel of a Source with interleaved samples.
+//
+// For example, SelectChannel(s, 2, 0) produces the left
+// channel of stereo audio.
+func SelectChannel(s Source, channels, channel int) Source {
+	if channel < 0 || channel >= channels {
+		panic("channel out of range")
+	}
+	weights := make([]float64, channels)
+	weights[channel] = 1
+	return &downmixSource{Wrapped: s, Weights: weights}
+}
+
+// A downmixSource computes a weighted sum of the channels
+// of each group of interleaved samples.
+type downmixSource struct {
+	Wrapped Source
+	Weights []float64
+
+	buffer    []float64
+	doneError error
+}
+
+func (d *downmixSource) ReadSamples(s []float64) (n int, err error) {
+	if d.doneError != nil {
+		return 0, d.doneError
+	}
+	channels := len(d.Weights)
+	if size := len(s) * channels; len(d.buffer) < size {
+		d.buffer = make([]float64, size)
+	}
+	buf := d.buffer[:len(s)*channels]
+	var have int
+	for have < len(buf) && err == nil {
+		var read int
+		read, err = d.Wrapped.ReadSamples(buf[have:])
+		have += read
+	}
+	if err != nil {
+		d.doneError = err
+	}
+	for n = 0; n < have/channels; n++ {
+		var sum float64
+		for j, w := range d.Weights {
+			sum += w * buf[n*channels+j]
+		}
+		s[n] = sum
+	}
+	return n, err
+}
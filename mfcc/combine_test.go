@@ -0,0 +1,101 @@
+package mfcc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConcat(t *testing.T) {
+	source := Concat(
+		&sliceSource{vec: []float64{1, 2, 3}, buffSize: 2},
+		&sliceSource{vec: []float64{}, buffSize: 2},
+		&sliceSource{vec: []float64{4, 5}, buffSize: 1},
+	)
+	actual := readAllSamples(t, source)
+	expected := []float64{1, 2, 3, 4, 5}
+	if len(actual) != len(expected) || !slicesClose(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestConcatKeepsSlice(t *testing.T) {
+	list := []Source{
+		&sliceSource{vec: []float64{1}, buffSize: 1},
+		&sliceSource{vec: []float64{2}, buffSize: 1},
+	}
+	readAllSamples(t, Concat(list...))
+	for i, source := range list {
+		if source == nil {
+			t.Errorf("source %d was removed from the caller's slice", i)
+		}
+	}
+}
+
+func TestMix(t *testing.T) {
+	source := Mix([]Source{
+		&sliceSource{vec: []float64{1, 2, 3, 4}, buffSize: 3},
+		&sliceSource{vec: []float64{1, 1}, buffSize: 1},
+	}, []float64{0.5, -2})
+	actual := readAllSamples(t, source)
+	expected := []float64{-1.5, -1, 1.5, 2}
+	if len(actual) != len(expected) || !slicesClose(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestMixError(t *testing.T) {
+	testErr := errors.New("test error")
+	source := Mix([]Source{
+		&sliceSource{vec: []float64{1, 2, 3, 4}, buffSize: 4},
+		Concat(&sliceSource{vec: []float64{1}, buffSize: 1}, &errorSource{testErr}),
+	}, []float64{1, 1})
+	var buf [10]float64
+	if _, err := source.ReadSamples(buf[:]); err != testErr {
+		t.Errorf("expected %v but got %v", testErr, err)
+	}
+	if _, err := source.ReadSamples(buf[:]); err != testErr {
+		t.Errorf("expected %v again but got %v", testErr, err)
+	}
+}
+
+func TestGainLimitSkip(t *testing.T) {
+	vec := []float64{1, 2, 3, 4, 5, 6, 7}
+	tests := []struct {
+		source   Source
+		expected []float64
+	}{
+		{Gain(&sliceSource{vec: vec, buffSize: 3}, 2), []float64{2, 4, 6, 8, 10, 12, 14}},
+		{Limit(&sliceSource{vec: vec, buffSize: 3}, 4), []float64{1, 2, 3, 4}},
+		{Limit(&sliceSource{vec: vec, buffSize: 3}, 10), vec},
+		{Limit(&sliceSource{vec: vec, buffSize: 3}, 0), []float64{}},
+		{Skip(&sliceSource{vec: vec, buffSize: 3}, 5), []float64{6, 7}},
+		{Skip(&sliceSource{vec: vec, buffSize: 3}, 10), []float64{}},
+		{Skip(Limit(&sliceSource{vec: vec, buffSize: 3}, 5), 1), []float64{2, 3, 4, 5}},
+	}
+	for i, test := range tests {
+		actual := readAllSamples(t, test.source)
+		if len(actual) != len(test.expected) || !slicesClose(actual, test.expected) {
+			t.Errorf("test %d: expected %v but got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestDownmix(t *testing.T) {
+	vec := []float64{1, 3, 2, 4, -1, 1, 5}
+	tests := []struct {
+		source   Source
+		expected []float64
+	}{
+		{Downmix(&sliceSource{vec: vec, buffSize: 3}, 2), []float64{2, 3, 0}},
+		{Downmix(&sliceSource{vec: vec, buffSize: 3}, 1), vec},
+		{SelectChannel(&sliceSource{vec: vec, buffSize: 3}, 2, 0), []float64{1, 2, -1}},
+		{SelectChannel(&sliceSource{vec: vec, buffSize: 3}, 2, 1), []float64{3, 4, 1}},
+		{SelectChannel(&sliceSource{vec: vec, buffSize: 2}, 3, 2), []float64{2, 1}},
+	}
+	for i, test := range tests {
+		actual := readAllSamples(t, test.source)
+		if len(actual) != len(test.expected) || !slicesClose(actual, test.expected) {
+			t.Errorf("test %d: expected %v but got %v", i, test.expected, actual)
+		}
+	}
+}
@@ -25,3 +25,179 @@ func dct(signal []float64, n int) []float64 {
 	}
 	return res
 }
+
+// fastDCTThreshold is the smallest product of the signal
+// length and coefficient count for which DCT uses an FFT
+// rather than computing each coefficient directly.
+const fastDCTThreshold = 2048
+
+// DCTNorm specifies the scaling of a discrete cosine
+// transform.
+type DCTNorm int
+
+const (
+	// NoDCTNorm uses the unscaled DCT-II,
+	//
+	//	X[k] = sum_n x[n]*cos(pi/N*(n+1/2)*k),
+	//
+	// which is what MFCC uses by default.
+	NoDCTNorm DCTNorm = iota
+
+	// OrthoDCTNorm scales the DCT-II to be orthonormal,
+	// multiplying X[0] by sqrt(1/N) and every other X[k]
+	// by sqrt(2/N).
+	// This matches the scaling used by Kaldi and librosa.
+	OrthoDCTNorm
+)
+
+// DCT computes the first n coefficients of the DCT-II of
+// a signal.
+//
+// Large transforms are computed in O(N*log(N)) time
+// using an FFT, as long as n is at most the length of
+// the signal.
+func DCT(signal []float64, n int, norm DCTNorm) []float64 {
+	var res []float64
+	if n <= len(signal) && len(signal)*n >= fastDCTThreshold {
+		res = fastDCT(signal, n)
+	} else {
+		res = dct(signal, n)
+	}
+	if norm == OrthoDCTNorm {
+		scaleDCT(res, len(signal), false)
+	}
+	return res
+}
+
+// InverseDCT computes a signal of the given size whose
+// DCT (using the same norm) begins with coeffs.
+//
+// If fewer than size coefficients are provided, the
+// missing ones are treated as 0, which yields a smoothed
+// version of the original signal.
+// For example, this can map a vector of MFCCs back to
+// log Mel bank energies.
+func InverseDCT(coeffs []float64, size int, norm DCTNorm) []float64 {
+	if len(coeffs) > size {
+		coeffs = coeffs[:size]
+	}
+	weights := append([]float64{}, coeffs...)
+	if norm == OrthoDCTNorm {
+		scaleDCT(weights, size, false)
+	} else {
+		scaleDCT(weights, size, true)
+	}
+	if len(weights)*size >= fastDCTThreshold {
+		return fastDCTIII(weights, size)
+	}
+	return dctIII(weights, size)
+}
+
+// scaleDCT applies the orthonormal scaling to DCT-II
+// coefficients of a signal of the given size, or the
+// scaling which turns a DCT-III into the inverse of the
+// unscaled DCT-II if inverse is set.
+func scaleDCT(coeffs []float64, size int, inverse bool) {
+	first, rest := math.Sqrt(1/float64(size)), math.Sqrt(2/float64(size))
+	if inverse {
+		first, rest = 1/float64(size), 2/float64(size)
+	}
+	for i := range coeffs {
+		if i == 0 {
+			coeffs[i] *= first
+		} else {
+			coeffs[i] *= rest
+		}
+	}
+}
+
+// dctIII computes the DCT-III
+//
+//	x[n] = sum_k X[k]*cos(pi/N*(n+1/2)*k)
+//
+// for a signal of size N.
+func dctIII(coeffs []float64, size int) []float64 {
+	res := make([]float64, size)
+	baseFreq := math.Pi / float64(size)
+	for k, c := range coeffs {
+		initArg := baseFreq * float64(k) * 0.5
+		curCos := math.Cos(initArg)
+		curSin := math.Sin(initArg)
+		addCos := curCos*curCos - curSin*curSin
+		addSin := 2 * curCos * curSin
+		for n := range res {
+			res[n] += c * curCos
+			curCos, curSin = curCos*addCos-curSin*addSin, curCos*addSin+addCos*curSin
+		}
+	}
+	return res
+}
+
+// fastDCT computes the same thing as dct using an FFT of
+// a reordered signal, following Makhoul (1980).
+//
+// The number of bins n must not exceed len(signal).
+func fastDCT(signal []float64, n int) []float64 {
+	size := len(signal)
+	plan := cachedFFTPlan(size)
+
+	reordered := make([]float64, size)
+	for i := 0; i < (size+1)/2; i++ {
+		reordered[i] = signal[2*i]
+	}
+	for i := 0; i < size/2; i++ {
+		reordered[size-1-i] = signal[2*i+1]
+	}
+	spectrum := make([]complex128, size/2+1)
+	plan.TransformReal(reordered, spectrum)
+
+	res := make([]float64, n)
+	for k := range res {
+		var v complex128
+		if k < len(spectrum) {
+			v = spectrum[k]
+		} else {
+			v = conj(spectrum[size-k])
+		}
+		sin, cos := math.Sincos(-math.Pi * float64(k) / float64(2*size))
+		res[k] = real(v)*cos - imag(v)*sin
+	}
+	return res
+}
+
+// fastDCTIII computes the same thing as dctIII using an
+// inverse FFT, by inverting the steps of fastDCT.
+func fastDCTIII(coeffs []float64, size int) []float64 {
+	plan := cachedFFTPlan(size)
+
+	// The DCT-III equals N times the inverse of the
+	// unscaled DCT-II after halving every coefficient but
+	// the first.
+	spectrum := make([]complex128, size)
+	coeff := func(k int) float64 {
+		if k >= len(coeffs) {
+			return 0
+		} else if k == 0 {
+			return coeffs[0]
+		}
+		return coeffs[k] / 2
+	}
+	for k := range spectrum {
+		var mirror float64
+		if k > 0 {
+			mirror = coeff(size - k)
+		}
+		sin, cos := math.Sincos(math.Pi * float64(k) / float64(2*size))
+		spectrum[k] = complex(cos, sin) * complex(coeff(k), -mirror)
+	}
+	plan.Inverse(spectrum, spectrum)
+
+	res := make([]float64, size)
+	for i := 0; i < (size+1)/2; i++ {
+		res[2*i] = real(spectrum[i]) * float64(size)
+	}
+	for i := 0; i < size/2; i++ {
+		res[2*i+1] = real(spectrum[size-1-i]) * float64(size)
+	}
+	return res
+}
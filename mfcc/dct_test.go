@@ -1,6 +1,7 @@
 package mfcc
 
 import (
+	"math"
 	"math/rand"
 	"testing"
 )
@@ -33,6 +34,89 @@ func TestDCT(t *testing.T) {
 	}
 }
 
+func TestFastDCT(t *testing.T) {
+	rand.Seed(123)
+	for _, size := range []int{1, 2, 5, 8, 26, 64, 100, 128} {
+		signal := randomSignal(size)
+		expected := dct(signal, size)
+		actual := fastDCT(signal, size)
+		if !slicesClose(actual, expected) {
+			t.Errorf("size %d: expected %v got %v", size, expected, actual)
+		}
+		expected = dctIII(signal, size)
+		actual = fastDCTIII(signal, size)
+		if !slicesClose(actual, expected) {
+			t.Errorf("size %d: expected DCT-III %v got %v", size, expected, actual)
+		}
+	}
+}
+
+func TestDCTOrtho(t *testing.T) {
+	rand.Seed(123)
+	for _, size := range []int{8, 100} {
+		signal := randomSignal(size)
+		coeffs := DCT(signal, size, OrthoDCTNorm)
+		if math.Abs(vectorNorm(coeffs)-vectorNorm(signal)) > 1e-8 {
+			t.Errorf("size %d: norm changed from %f to %f", size, vectorNorm(signal),
+				vectorNorm(coeffs))
+		}
+		expected := dct(signal, 3)
+		expected[0] *= math.Sqrt(1 / float64(size))
+		expected[1] *= math.Sqrt(2 / float64(size))
+		expected[2] *= math.Sqrt(2 / float64(size))
+		if !slicesClose(coeffs[:3], expected) {
+			t.Errorf("size %d: expected %v got %v", size, expected, coeffs[:3])
+		}
+	}
+}
+
+func TestDCTExtraBins(t *testing.T) {
+	rand.Seed(123)
+	for _, size := range []int{8, 40} {
+		signal := randomSignal(size)
+		n := size * 3 / 2
+		expected := dct(signal, n)
+		actual := DCT(signal, n, NoDCTNorm)
+		if !slicesClose(actual, expected) {
+			t.Errorf("size %d: expected %v got %v", size, expected, actual)
+		}
+	}
+
+	signal := randomSignal(8000)
+	coeffs := readAllCoeffs(t, MFCC(&SliceSource{Slice: signal}, 8000,
+		&Options{MelCount: 40, KeepCount: 60}))
+	for i, vec := range coeffs {
+		if len(vec) != 60 {
+			t.Fatalf("vector %d: expected 60 coefficients but got %d", i, len(vec))
+		}
+	}
+}
+
+func TestInverseDCT(t *testing.T) {
+	rand.Seed(123)
+	for _, norm := range []DCTNorm{NoDCTNorm, OrthoDCTNorm} {
+		for _, size := range []int{1, 8, 26, 100} {
+			signal := randomSignal(size)
+			actual := InverseDCT(DCT(signal, size, norm), size, norm)
+			if len(actual) != size {
+				t.Errorf("norm %d size %d: bad length %d", norm, size, len(actual))
+			} else if !slicesClose(actual, signal) {
+				t.Errorf("norm %d size %d: expected %v got %v", norm, size, signal, actual)
+			}
+		}
+
+		// Truncated coefficients give the least-squares
+		// approximation for an orthogonal basis, so the
+		// DCT of the reconstruction should match.
+		signal := randomSignal(26)
+		coeffs := DCT(signal, 13, norm)
+		smooth := InverseDCT(coeffs, 26, norm)
+		if actual := DCT(smooth, 13, norm); !slicesClose(actual, coeffs) {
+			t.Errorf("norm %d: expected %v got %v", norm, coeffs, actual)
+		}
+	}
+}
+
 func BenchmarkDCT(b *testing.B) {
 	rand.Seed(123)
 	input := make([]float64, dctBenchSignalSize)
@@ -44,3 +128,28 @@ func BenchmarkDCT(b *testing.B) {
 		dct(input, dctBenchBinCount)
 	}
 }
+
+func BenchmarkDCTLarge(b *testing.B) {
+	rand.Seed(123)
+	input := randomSignal(512)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DCT(input, 512, NoDCTNorm)
+	}
+}
+
+func randomSignal(size int) []float64 {
+	res := make([]float64, size)
+	for i := range res {
+		res[i] = rand.NormFloat64()
+	}
+	return res
+}
+
+func vectorNorm(v []float64) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,117 @@
+package mfcc
+
+// AddDeltas generates a CoeffSource which wraps c and
+// augments every vector of coefficients with its
+// derivatives, up to the given order.
+//
+// Derivatives are estimated with the standard regression
+// formula over N=window frames on each side:
+//
+//	d[t] = sum_{n=1}^{N} n*(c[t+n]-c[t-n]) / (2*sum_{n=1}^{N} n^2)
+//
+// Frames beyond the start or end of the input are taken
+// to be copies of the first or last frame, respectively.
+// Higher-order derivatives are computed by applying the
+// same formula to the derivatives of the previous order.
+//
+// For example, for input coefficients [a,b,c] and an
+// order of 2, the resulting source would produce
+// [a,b,c,da,db,dc,dda,ddb,ddc].
+//
+// Since each output requires window frames of lookahead
+// per order, the source reads ahead of its outputs by
+// window*order frames.
+func AddDeltas(c CoeffSource, window, order int) FrameSource {
+	if window < 1 {
+		panic("delta window must be at least 1")
+	} else if order < 0 {
+		panic("delta order must not be negative")
+	}
+	res := Frames(c)
+	for i := 1; i <= order; i++ {
+		res = &regressionSource{
+			Wrapped:    res,
+			Window:     window,
+			BlockCount: i,
+		}
+	}
+	return res
+}
+
+// A regressionSource appends the regression derivative of
+// the last block of each coefficient vector, where every
+// vector from the wrapped source is made up of BlockCount
+// equally sized blocks.
+type regressionSource struct {
+	Wrapped    FrameSource
+	Window     int
+	BlockCount int
+
+	// frames stores up to Window frames before the next
+	// output frame, followed by the next output frame and
+	// whatever lookahead has been read.
+	frames    []*Frame
+	center    int
+	doneError error
+}
+
+func (r *regressionSource) NextCoeffs() ([]float64, error) {
+	return coeffsOf(r.NextFrame())
+}
+
+func (r *regressionSource) NextFrame() (*Frame, error) {
+	for r.doneError == nil && len(r.frames) <= r.center+r.Window {
+		next, err := r.Wrapped.NextFrame()
+		if err != nil {
+			r.doneError = err
+		} else {
+			r.frames = append(r.frames, next)
+		}
+	}
+	if r.center >= len(r.frames) {
+		return nil, r.doneError
+	}
+
+	curFrame := r.frames[r.center]
+	cur := curFrame.Coeffs
+	blockSize := len(cur) / r.BlockCount
+	blockStart := len(cur) - blockSize
+
+	res := make([]float64, len(cur)+blockSize)
+	copy(res, cur)
+	var denom float64
+	for n := 1; n <= r.Window; n++ {
+		next := r.frames[minInt(r.center+n, len(r.frames)-1)].Coeffs
+		last := r.frames[maxInt(r.center-n, 0)].Coeffs
+		for i := 0; i < blockSize; i++ {
+			res[len(cur)+i] += float64(n) * (next[blockStart+i] - last[blockStart+i])
+		}
+		denom += 2 * float64(n*n)
+	}
+	for i := len(cur); i < len(res); i++ {
+		res[i] /= denom
+	}
+
+	r.center++
+	if r.center > r.Window {
+		r.frames[0] = nil
+		r.frames = r.frames[1:]
+		r.center--
+	}
+
+	return curFrame.withCoeffs(res), nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,94 @@
+package mfcc
+
+import (
+	"io"
+	"math/rand"
+	"testing"
+)
+
+type sliceCoeffSource struct {
+	vecs [][]float64
+}
+
+func (s *sliceCoeffSource) NextCoeffs() ([]float64, error) {
+	if len(s.vecs) == 0 {
+		return nil, io.EOF
+	}
+	res := s.vecs[0]
+	s.vecs = s.vecs[1:]
+	return res, nil
+}
+
+func TestAddDeltasRamp(t *testing.T) {
+	var vecs [][]float64
+	for i := 0; i < 8; i++ {
+		vecs = append(vecs, []float64{float64(i) * 2, 3})
+	}
+	actual := readAllCoeffs(t, AddDeltas(&sliceCoeffSource{vecs: vecs}, 2, 1))
+	if len(actual) != len(vecs) {
+		t.Fatalf("expected %d frames but got %d", len(vecs), len(actual))
+	}
+	for i := 2; i < len(vecs)-2; i++ {
+		expected := []float64{float64(i) * 2, 3, 2, 0}
+		if len(actual[i]) != len(expected) || !slicesClose(actual[i], expected) {
+			t.Errorf("frame %d: expected %v got %v", i, expected, actual[i])
+		}
+	}
+	first := []float64{0, 3, (1*2 + 2*4) / 10.0, 0}
+	if !slicesClose(actual[0], first) {
+		t.Errorf("first frame: expected %v got %v", first, actual[0])
+	}
+}
+
+func TestAddDeltasOrder(t *testing.T) {
+	rand.Seed(123)
+	var vecs [][]float64
+	for i := 0; i < 20; i++ {
+		vec := make([]float64, 13)
+		for j := range vec {
+			vec[j] = rand.NormFloat64()
+		}
+		vecs = append(vecs, vec)
+	}
+	deltas := batchDeltas(vecs, 2)
+	deltaDeltas := batchDeltas(deltas, 2)
+
+	source := AddDeltas(&sliceCoeffSource{vecs: append([][]float64{}, vecs...)}, 2, 2)
+	actual := readAllCoeffs(t, source)
+	if len(actual) != len(vecs) {
+		t.Fatalf("expected %d frames but got %d", len(vecs), len(actual))
+	}
+	for i, vec := range actual {
+		expected := append(append(append([]float64{}, vecs[i]...), deltas[i]...),
+			deltaDeltas[i]...)
+		if len(vec) != 39 {
+			t.Errorf("frame %d: expected 39 coefficients but got %d", i, len(vec))
+		} else if !slicesClose(vec, expected) {
+			t.Errorf("frame %d: expected %v got %v", i, expected, vec)
+		}
+	}
+
+	if res := readAllCoeffs(t, AddDeltas(&sliceCoeffSource{}, 2, 2)); len(res) != 0 {
+		t.Errorf("expected no frames but got %d", len(res))
+	}
+}
+
+func batchDeltas(vecs [][]float64, window int) [][]float64 {
+	res := make([][]float64, len(vecs))
+	for t := range vecs {
+		res[t] = make([]float64, len(vecs[t]))
+		var denom float64
+		for n := 1; n <= window; n++ {
+			next := vecs[minInt(t+n, len(vecs)-1)]
+			last := vecs[maxInt(t-n, 0)]
+			for i := range res[t] {
+				res[t][i] += float64(n) * (next[i] - last[i])
+			}
+			denom += 2 * float64(n*n)
+		}
+		for i := range res[t] {
+			res[t][i] /= denom
+		}
+	}
+	return res
+}
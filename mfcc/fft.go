@@ -1,15 +1,15 @@
 package mfcc
 
-import "math"
-
 // fftBins stores the dot products of a signal with a
 // basis of sinusoids.
 //
-// Let N be len(signal), and assume it is a power of 2.
+// Let N be len(signal).
 // The i-th dot product in Cos, where i is between 0 and
-// N/2 inclusive, are with is cos(2*pi/N*i).
+// N/2 inclusive (rounding down), are with is
+// cos(2*pi/N*i).
 // The j-th dot product in Sin, where j is between 0 and
-// N/2-1 inclusive, are with sin(2*pi/N*i).
+// N-N/2-2 inclusive, are with sin(2*pi/N*(j+1)).
+// In total, there are N dot products.
 type fftBins struct {
 	Cos []float64
 	Sin []float64
@@ -17,36 +17,21 @@ type fftBins struct {
 
 // fft computes dot products of the signal with
 // various sinusoids.
+//
+// Signals whose lengths are powers of 2 use a fast path.
+// Other lengths are handled with Bluestein's algorithm.
 func fft(signal []float64) fftBins {
-	temp := make([]float64, len(signal))
-	signalCopy := make([]float64, len(signal))
-	copy(signalCopy, signal)
-
-	basePeriod := 2 * math.Pi / float64(len(signal))
-	sines := make([]float64, len(signal)/4)
-	cosines := make([]float64, len(signal)/4)
-	for i := range cosines {
-		if i < 2 || i%100 == 0 {
-			cosines[i] = math.Cos(basePeriod * float64(i))
-			sines[i] = math.Sin(basePeriod * float64(i))
-		} else {
-			// Angle sum formulas for cosine and sine.
-			cosines[i] = cosines[i-1]*cosines[1] - sines[i-1]*sines[1]
-			sines[i] = sines[i-1]*cosines[1] + cosines[i-1]*sines[1]
-		}
-	}
-
-	return destructiveFFT(signalCopy, temp, sines, cosines, 0)
+	return cachedFFTPlan(len(signal)).bins(signal)
 }
 
 func (f fftBins) powerSpectrum() []float64 {
 	scaleFactor := 1 / float64(len(f.Cos)+len(f.Sin))
-	n := len(f.Cos)
-	res := make([]float64, n)
-	res[0] = f.Cos[0] * f.Cos[0] * scaleFactor
-	res[n-1] = f.Cos[n-1] * f.Cos[n-1] * scaleFactor
+	res := make([]float64, len(f.Cos))
+	for i, c := range f.Cos {
+		res[i] = c * c * scaleFactor
+	}
 	for i, s := range f.Sin {
-		res[i+1] = (s*s + f.Cos[i+1]*f.Cos[i+1]) * scaleFactor
+		res[i+1] += s * s * scaleFactor
 	}
 	return res
 }
@@ -55,22 +40,18 @@ func destructiveFFT(signal []float64, temp []float64, sines, cosines []float64,
 	depth uint) fftBins {
 	n := len(signal)
 	if n == 1 {
-		return fftBins{Cos: []float64{signal[0]}}
+		temp[0] = signal[0]
+		return fftBins{Cos: temp[:1]}
 	} else if n == 2 {
-		return fftBins{
-			Cos: []float64{signal[0] + signal[1], signal[0] - signal[1]},
-		}
+		temp[0] = signal[0] + signal[1]
+		temp[1] = signal[0] - signal[1]
+		return fftBins{Cos: temp[:2]}
 	} else if n == 4 {
-		return fftBins{
-			Cos: []float64{
-				signal[0] + signal[1] + signal[2] + signal[3],
-				signal[0] - signal[2],
-				signal[0] - signal[1] + signal[2] - signal[3],
-			},
-			Sin: []float64{
-				signal[1] - signal[3],
-			},
-		}
+		temp[0] = signal[0] + signal[1] + signal[2] + signal[3]
+		temp[1] = signal[0] - signal[2]
+		temp[2] = signal[0] - signal[1] + signal[2] - signal[3]
+		temp[3] = signal[1] - signal[3]
+		return fftBins{Cos: temp[:3], Sin: temp[3:]}
 	} else if n&1 != 0 {
 		panic("input must be a power of 2")
 	}
@@ -1,11 +1,16 @@
 package mfcc
 
 import (
+	"math"
 	"math/rand"
 	"testing"
 )
 
-const fftBenchSize = 512
+const (
+	fftBenchSize      = 512
+	fftBenchOddSize   = 400
+	fftBenchPrimeSize = 401
+)
 
 func TestFFT(t *testing.T) {
 	inputs := [][]float64{
@@ -61,6 +66,34 @@ func TestFFTPower(t *testing.T) {
 	}
 }
 
+func TestFFTNonPowerOf2(t *testing.T) {
+	rand.Seed(123)
+	for _, size := range []int{3, 5, 6, 7, 12, 100, 320, 400, 401} {
+		input := make([]float64, size)
+		for i := range input {
+			input[i] = rand.NormFloat64()
+		}
+		res := fft(input)
+		actual := append(res.Cos, res.Sin...)
+		expected := naiveFFTBins(input)
+		if len(res.Cos) != size/2+1 || len(actual) != size {
+			t.Errorf("size %d: bad lengths %d, %d", size, len(res.Cos), len(res.Sin))
+		} else if !slicesClose(actual, expected) {
+			t.Errorf("size %d: expected %v but got %v", size, expected, actual)
+		}
+	}
+}
+
+func TestFFTPowerOdd(t *testing.T) {
+	actual := fft([]float64{1, 2, 3}).powerSpectrum()
+	expected := []float64{12, 1}
+	if len(actual) != len(expected) {
+		t.Errorf("expected len %d but got len %d", len(expected), len(actual))
+	} else if !slicesClose(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
+
 func BenchmarkFFT(b *testing.B) {
 	rand.Seed(123)
 	inputVec := make([]float64, fftBenchSize)
@@ -73,3 +106,51 @@ func BenchmarkFFT(b *testing.B) {
 		fft(inputVec)
 	}
 }
+
+func BenchmarkFFTNonPowerOf2(b *testing.B) {
+	rand.Seed(123)
+	inputVec := make([]float64, fftBenchOddSize)
+	for i := range inputVec {
+		inputVec[i] = rand.NormFloat64()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fft(inputVec)
+	}
+}
+
+func BenchmarkFFTPrime(b *testing.B) {
+	rand.Seed(123)
+	inputVec := make([]float64, fftBenchPrimeSize)
+	for i := range inputVec {
+		inputVec[i] = rand.NormFloat64()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fft(inputVec)
+	}
+}
+
+// naiveFFTBins computes the same dot products as fft in
+// O(N^2) time.
+func naiveFFTBins(signal []float64) []float64 {
+	n := len(signal)
+	var res []float64
+	for i := 0; i <= n/2; i++ {
+		var sum float64
+		for j, x := range signal {
+			sum += x * math.Cos(2*math.Pi/float64(n)*float64(i*j))
+		}
+		res = append(res, sum)
+	}
+	for i := 1; len(res) < n; i++ {
+		var sum float64
+		for j, x := range signal {
+			sum += x * math.Sin(2*math.Pi/float64(n)*float64(i*j))
+		}
+		res = append(res, sum)
+	}
+	return res
+}
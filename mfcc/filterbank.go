@@ -0,0 +1,143 @@
+package mfcc
+
+import "math"
+
+// A FrequencyScale maps frequencies to a perceptual (or
+// linear) scale on which filters are evenly spaced.
+type FrequencyScale interface {
+	FromHertz(h float64) float64
+	ToHertz(s float64) float64
+}
+
+// LinearScale is a FrequencyScale which measures
+// frequencies in Hertz.
+type LinearScale struct{}
+
+func (LinearScale) FromHertz(h float64) float64 {
+	return h
+}
+
+func (LinearScale) ToHertz(s float64) float64 {
+	return s
+}
+
+// BarkScale is a FrequencyScale which uses Traunmüller's
+// approximation of the Bark scale,
+//
+//	z = 26.81*f/(1960+f) - 0.53.
+type BarkScale struct{}
+
+func (BarkScale) FromHertz(h float64) float64 {
+	return 26.81*h/(1960+h) - 0.53
+}
+
+func (BarkScale) ToHertz(z float64) float64 {
+	return 1960 * (z + 0.53) / (26.28 - z)
+}
+
+// ERBScale is a FrequencyScale which measures the number
+// of equivalent rectangular bandwidths below a frequency,
+// following Glasberg and Moore (1990):
+//
+//	e = 21.4*log10(1 + 0.00437*f).
+type ERBScale struct{}
+
+func (ERBScale) FromHertz(h float64) float64 {
+	return 21.4 * math.Log10(1+0.00437*h)
+}
+
+func (ERBScale) ToHertz(e float64) float64 {
+	return (math.Pow(10, e/21.4) - 1) / 0.00437
+}
+
+// FilterBankOptions describes how to lay out the filters
+// of a FilterBank.
+type FilterBankOptions struct {
+	// Scale is the scale on which the filters are evenly
+	// spaced.
+	// If this is nil, HTKMelScale is used.
+	Scale FrequencyScale
+
+	// Fractional can be set to place the corners of each
+	// filter at their exact frequencies, rather than
+	// rounding them to the nearest FFT bins.
+	Fractional bool
+
+	// Norm determines how the filters are scaled.
+	Norm FilterNorm
+}
+
+func (f FilterBankOptions) scale() FrequencyScale {
+	if f.Scale == nil {
+		return HTKMelScale
+	}
+	return f.Scale
+}
+
+// A FilterBank is a set of overlapping triangular
+// filters which are applied to a power spectrum.
+//
+// This is the filter bank which MFCC uses to compute Mel
+// bank energies.
+type FilterBank struct {
+	// Weights stores one row per filter.
+	// Weights[i][j] is the weight of the j-th entry of the
+	// power spectrum in the i-th filter.
+	Weights [][]float64
+
+	// Centers stores the frequency, in Hertz, at which
+	// each filter peaks.
+	Centers []float64
+}
+
+// NewMelFilterBank creates the FilterBank that MFCC uses
+// with default options.
+//
+// The fftSize and sampleRate determine the frequencies
+// of the power spectrum bins, of which there are
+// fftSize/2+1.
+// The filters are evenly spaced on the Mel scale from
+// minFreq to maxFreq.
+func NewMelFilterBank(fftSize, sampleRate, binCount int,
+	minFreq, maxFreq float64) *FilterBank {
+	return NewFilterBank(fftSize, sampleRate, binCount, minFreq, maxFreq, nil)
+}
+
+// NewFilterBank is like NewMelFilterBank, but with
+// control over the scale and shape of the filters.
+//
+// If opts is nil, the result is the same as from
+// NewMelFilterBank.
+func NewFilterBank(fftSize, sampleRate, binCount int, minFreq, maxFreq float64,
+	opts *FilterBankOptions) *FilterBank {
+	if opts == nil {
+		opts = &FilterBankOptions{}
+	}
+	points := filterPoints(sampleRate, binCount, minFreq, maxFreq, opts.scale())
+	binner := pointsMelBinner(fftSize, sampleRate, points, *opts)
+	res := &FilterBank{
+		Weights: make([][]float64, binCount),
+		Centers: append([]float64{}, points[1:binCount+1]...),
+	}
+	for i, bin := range binner {
+		res.Weights[i] = bin.denseWeights(fftSize/2 + 1)
+	}
+	return res
+}
+
+// Apply computes the energy in every filter, given a
+// power spectrum like the ones from PowerSpectrum.
+func (f *FilterBank) Apply(powerSpectrum []float64) []float64 {
+	res := make([]float64, len(f.Weights))
+	for i, row := range f.Weights {
+		if len(row) != len(powerSpectrum) {
+			panic("power spectrum has incorrect size")
+		}
+		var sum float64
+		for j, w := range row {
+			sum += w * powerSpectrum[j]
+		}
+		res[i] = sum
+	}
+	return res
+}
@@ -0,0 +1,87 @@
+package mfcc
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestFrequencyScales(t *testing.T) {
+	scales := []FrequencyScale{LinearScale{}, BarkScale{}, ERBScale{}, HTKMelScale,
+		SlaneyMelScale, OShaughnessyMelScale}
+	for i, scale := range scales {
+		last := math.Inf(-1)
+		for _, h := range []float64{0, 50, 300, 1000, 3000, 8000, 20000} {
+			scaled := scale.FromHertz(h)
+			if scaled <= last {
+				t.Errorf("scale %d: not increasing at %f Hz", i, h)
+			}
+			last = scaled
+			if actual := scale.ToHertz(scaled); math.Abs(actual-h) > 1e-6 {
+				t.Errorf("scale %d: expected %f Hz but got %f", i, h, actual)
+			}
+		}
+	}
+
+	// Reference values from Traunmüller (1990) and
+	// Glasberg and Moore (1990).
+	if actual := (BarkScale{}).FromHertz(1000); math.Abs(actual-8.527) > 1e-3 {
+		t.Errorf("expected 8.527 Bark but got %f", actual)
+	}
+	if actual := (ERBScale{}).FromHertz(1000); math.Abs(actual-15.621) > 1e-3 {
+		t.Errorf("expected 15.621 ERBs but got %f", actual)
+	}
+}
+
+func TestMelFilterBank(t *testing.T) {
+	rand.Seed(123)
+	bank := NewMelFilterBank(512, 16000, 26, 300, 8000)
+	binner := newMelBinner(512, 16000, 26, 300, 8000, FilterBankOptions{})
+	if len(bank.Weights) != 26 || len(bank.Centers) != 26 {
+		t.Fatalf("bad sizes %d, %d", len(bank.Weights), len(bank.Centers))
+	}
+	powers := make([]float64, 257)
+	for i := range powers {
+		powers[i] = rand.Float64()
+	}
+	expected := binner.applyPowers(powers)
+	if actual := bank.Apply(powers); !slicesClose(actual, expected) {
+		t.Errorf("expected %v got %v", expected, actual)
+	}
+	for i, row := range bank.Weights {
+		if len(row) != 257 {
+			t.Fatalf("filter %d: bad size %d", i, len(row))
+		}
+	}
+}
+
+func TestFilterBankCenters(t *testing.T) {
+	scales := []FrequencyScale{LinearScale{}, BarkScale{}, ERBScale{}, SlaneyMelScale}
+	for i, scale := range scales {
+		bank := NewFilterBank(1024, 16000, 20, 100, 7000, &FilterBankOptions{
+			Scale:      scale,
+			Fractional: true,
+		})
+		step := (scale.FromHertz(7000) - scale.FromHertz(100)) / 21
+		for j, center := range bank.Centers {
+			expected := scale.FromHertz(100) + float64(j+1)*step
+			if actual := scale.FromHertz(center); math.Abs(actual-expected) > 1e-8 {
+				t.Errorf("scale %d filter %d: expected %f got %f", i, j, expected, actual)
+			}
+
+			// The filter should peak at one of the two bins
+			// around its center.
+			var peak int
+			for k, w := range bank.Weights[j] {
+				if w > bank.Weights[j][peak] {
+					peak = k
+				}
+			}
+			centerBin := center * 1024 / 16000
+			if math.Abs(float64(peak)-centerBin) > 1 {
+				t.Errorf("scale %d filter %d: peak at %d but center at %f", i, j, peak,
+					centerBin)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package mfcc
+
+import "time"
+
+// A Frame is a vector of coefficients along with the
+// span of audio that it describes.
+type Frame struct {
+	// Index is the index of the frame in its stream,
+	// starting at 0.
+	Index int
+
+	// Start and End are the times, relative to the start
+	// of the audio, of the first sample in the frame and
+	// of the end of the last sample in the frame.
+	// Frames usually overlap, so a frame's End is often
+	// after the next frame's Start.
+	//
+	// If the times are unknown, both are 0.
+	Start time.Duration
+	End   time.Duration
+
+	Coeffs []float64
+}
+
+// A FrameSource is a CoeffSource which can also report
+// where each vector of coefficients comes from.
+//
+// Every CoeffSource in this package is a FrameSource.
+// Wrappers such as AddDeltas and NormalizeUtterance pass
+// along the timing of the frames they wrap.
+type FrameSource interface {
+	CoeffSource
+
+	// NextFrame is like NextCoeffs, but it returns the
+	// coefficients in a Frame.
+	// NextFrame and NextCoeffs read from the same stream,
+	// so each frame is returned by one or the other.
+	NextFrame() (*Frame, error)
+}
+
+// Frames converts a CoeffSource into a FrameSource.
+//
+// If c is already a FrameSource, it is returned as-is.
+// Otherwise, the frames are numbered as they are read,
+// but their times are unknown.
+func Frames(c CoeffSource) FrameSource {
+	if f, ok := c.(FrameSource); ok {
+		return f
+	}
+	return &indexedSource{Wrapped: c}
+}
+
+type indexedSource struct {
+	Wrapped CoeffSource
+	index   int
+}
+
+func (i *indexedSource) NextCoeffs() ([]float64, error) {
+	return coeffsOf(i.NextFrame())
+}
+
+func (i *indexedSource) NextFrame() (*Frame, error) {
+	vec, err := i.Wrapped.NextCoeffs()
+	if err != nil {
+		return nil, err
+	}
+	res := &Frame{Index: i.index, Coeffs: vec}
+	i.index++
+	return res, nil
+}
+
+// withCoeffs creates a copy of f with different
+// coefficients.
+func (f *Frame) withCoeffs(coeffs []float64) *Frame {
+	res := *f
+	res.Coeffs = coeffs
+	return &res
+}
+
+// coeffsOf implements NextCoeffs in terms of NextFrame.
+func coeffsOf(f *Frame, err error) ([]float64, error) {
+	if err != nil {
+		return nil, err
+	}
+	return f.Coeffs, nil
+}
+
+// sampleTime converts a number of samples to a duration.
+func sampleTime(samples, sampleRate int) time.Duration {
+	return time.Duration(int64(samples) * int64(time.Second) / int64(sampleRate))
+}
@@ -0,0 +1,108 @@
+package mfcc
+
+import (
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestMFCCFrameTimes(t *testing.T) {
+	options := &Options{
+		NativeSampleRate: true,
+		Window:           25 * time.Millisecond,
+		Overlap:          15 * time.Millisecond,
+	}
+	for _, count := range []int{0, 100, 16000, 16123} {
+		signal := make([]float64, count)
+		for i := range signal {
+			signal[i] = rand.NormFloat64()
+		}
+		frames := readAllFrames(t, MFCC(&SliceSource{Slice: signal}, 16000, options))
+		expectedCount := NewFrameLayout(16000, options).FrameCount(count)
+		if len(frames) != expectedCount {
+			t.Errorf("%d samples: expected %d frames but got %d", count, expectedCount,
+				len(frames))
+		}
+		total := time.Duration(count) * time.Second / 16000
+		for i, frame := range frames {
+			start := time.Duration(i) * 10 * time.Millisecond
+			end := start + 25*time.Millisecond
+			if end > total {
+				end = total
+			}
+			if frame.Index != i || frame.Start != start || frame.End != end {
+				t.Errorf("%d samples: frame %d should be %d [%v, %v] but got %d [%v, %v]",
+					count, i, i, start, end, frame.Index, frame.Start, frame.End)
+			}
+		}
+	}
+}
+
+func TestFramePropagation(t *testing.T) {
+	signal := make([]float64, 8000)
+	for i := range signal {
+		signal[i] = rand.NormFloat64()
+	}
+	newSource := func() FrameSource {
+		return MFCC(&SliceSource{Slice: signal}, 8000, nil)
+	}
+	expected := readAllFrames(t, newSource())
+
+	var stats CMVNStats
+	stats.AddAll(newSource())
+	wrappers := map[string]FrameSource{
+		"AddVelocities":      AddVelocities(newSource()),
+		"AddDeltas":          AddDeltas(newSource(), 2, 2),
+		"JoinCoeffs":         JoinCoeffs(newSource(), newSource()),
+		"NormalizeUtterance": NormalizeUtterance(newSource(), true),
+		"NormalizeSliding":   NormalizeSliding(newSource(), 10, true),
+		"NormalizeGlobal":    NormalizeGlobal(newSource(), &stats, true),
+	}
+	for name, source := range wrappers {
+		actual := readAllFrames(t, source)
+		if len(actual) != len(expected) {
+			t.Errorf("%s: expected %d frames but got %d", name, len(expected), len(actual))
+			continue
+		}
+		for i, frame := range actual {
+			x := expected[i]
+			if frame.Index != x.Index || frame.Start != x.Start || frame.End != x.End {
+				t.Errorf("%s: frame %d has index %d and times [%v, %v]", name, i,
+					frame.Index, frame.Start, frame.End)
+			}
+		}
+	}
+}
+
+func TestFramesAdapter(t *testing.T) {
+	vecs := [][]float64{{1, 2}, {3, 4}, {5, 6}}
+	frames := readAllFrames(t, Frames(&sliceCoeffSource{vecs: vecs}))
+	if len(frames) != len(vecs) {
+		t.Fatalf("expected %d frames but got %d", len(vecs), len(frames))
+	}
+	for i, frame := range frames {
+		if frame.Index != i || frame.Start != 0 || frame.End != 0 ||
+			!slicesClose(frame.Coeffs, vecs[i]) {
+			t.Errorf("bad frame %d: %+v", i, frame)
+		}
+	}
+
+	source := MFCC(&SliceSource{Slice: []float64{1, 2, 3}}, 8000, nil)
+	if Frames(source) != source {
+		t.Error("FrameSource should be returned as-is")
+	}
+}
+
+func readAllFrames(t *testing.T, f FrameSource) []*Frame {
+	var res []*Frame
+	for {
+		frame, err := f.NextFrame()
+		if err == io.EOF {
+			return res
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, frame)
+	}
+}
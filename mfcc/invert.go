@@ -0,0 +1,359 @@
+package mfcc
+
+import (
+	"math"
+	"math/rand"
+)
+
+const DefaultInvertIterations = 32
+
+// invertRidge is the regularization strength, relative to
+// the average filter energy, used when inverting a filter
+// bank.
+const invertRidge = 1e-3
+
+// InvertOptions stores configuration options for turning
+// features back into audio.
+type InvertOptions struct {
+	// SampleRate is the sample rate of the reconstructed
+	// audio.
+	// If this is 0, the sample rate which was used to
+	// compute the features is used.
+	SampleRate int
+
+	// Iterations is the number of Griffin-Lim iterations
+	// used to recover the phase of each frame.
+	// If this is 0, DefaultInvertIterations is used.
+	Iterations int
+
+	// Resampling is the algorithm used to convert the
+	// audio from the rate at which features were computed
+	// to SampleRate.
+	Resampling ResampleQuality
+
+	// Seed seeds the random initial phases.
+	Seed int64
+}
+
+// InvertMFCC approximately reconstructs the audio from
+// which MFCCs were computed.
+//
+// The sampleRate and options must be the same as the
+// ones which were passed to MFCC.
+// If inv is nil, default options are used.
+//
+// Since MFCCs discard the phase and most of the fine
+// structure of the spectrum, the result only resembles
+// the original audio.
+// It is mainly useful for hearing what the features
+// preserve.
+func InvertMFCC(coeffs [][]float64, sampleRate int, options *Options,
+	inv *InvertOptions) []float64 {
+	if options == nil {
+		options = &Options{}
+	}
+	melCount := intOrDefault(options.MelCount, DefaultMelCount)
+
+	banks := make([][]float64, len(coeffs))
+	var energies []float64
+	for i, vec := range coeffs {
+		cepstrum := append([]float64{}, vec...)
+		switch options.Energy {
+		case AppendEnergy:
+			cepstrum = cepstrum[:len(cepstrum)-1]
+		case EnergyAsC0:
+			energies = append(energies, math.Exp(cepstrum[0]))
+			cepstrum[0] = 0
+		}
+		if l := options.Lifter; l != 0 {
+			for j := range cepstrum {
+				if scale := 1 + l/2*math.Sin(math.Pi*float64(j)/l); scale != 0 {
+					cepstrum[j] /= scale
+				}
+			}
+		}
+		banks[i] = InverseDCT(cepstrum, melCount, options.DCTNorm)
+	}
+
+	powers := invertLogBanks(banks, sampleRate, options)
+	if energies != nil {
+		// C0 was replaced, so the loudness of each frame
+		// must come from its energy instead.
+		fftSize := NewFrameLayout(sampleRate, options).FFTSize
+		for i, p := range powers {
+			if e := spectrumEnergy(p, fftSize); e > 0 {
+				for j := range p {
+					p[j] *= energies[i] / e
+				}
+			}
+		}
+	}
+	return InvertPowerSpectrum(powers, sampleRate, options, inv)
+}
+
+// InvertLogFilterBank is like InvertMFCC, but for the
+// output of LogFilterBank.
+func InvertLogFilterBank(banks [][]float64, sampleRate int, options *Options,
+	inv *InvertOptions) []float64 {
+	if options == nil {
+		options = &Options{}
+	}
+	return InvertPowerSpectrum(invertLogBanks(banks, sampleRate, options),
+		sampleRate, options, inv)
+}
+
+// InvertPowerSpectrum is like InvertMFCC, but for the
+// output of PowerSpectrum.
+//
+// It uses the Griffin-Lim algorithm to find a signal
+// whose frames have the given power spectra.
+func InvertPowerSpectrum(powers [][]float64, sampleRate int, options *Options,
+	inv *InvertOptions) []float64 {
+	if options == nil {
+		options = &Options{}
+	}
+	if inv == nil {
+		inv = &InvertOptions{}
+	}
+	layout := NewFrameLayout(sampleRate, options)
+
+	stft := &shortTimeFFT{
+		layout: layout,
+		plan:   cachedFFTPlan(layout.FFTSize),
+		window: make([]float64, layout.FrameSize),
+	}
+	if options.WindowFunc != nil {
+		copy(stft.window, (&windowCache{Func: options.WindowFunc}).Coeffs(layout.FrameSize))
+	} else {
+		for i := range stft.window {
+			stft.window[i] = 1
+		}
+	}
+
+	gen := rand.New(rand.NewSource(inv.Seed))
+	magnitudes := make([][]float64, len(powers))
+	spectra := make([][]complex128, len(powers))
+	for i, p := range powers {
+		magnitudes[i] = make([]float64, len(p))
+		spectra[i] = make([]complex128, len(p))
+		for j, x := range p {
+			magnitudes[i][j] = math.Sqrt(math.Max(x, 0) * float64(layout.FFTSize))
+			sin, cos := math.Sincos(gen.Float64() * 2 * math.Pi)
+			spectra[i][j] = complex(magnitudes[i][j]*cos, magnitudes[i][j]*sin)
+		}
+	}
+
+	signal := stft.Inverse(spectra)
+	for i := 0; i < intOrDefault(inv.Iterations, DefaultInvertIterations); i++ {
+		spectra = stft.Forward(signal, len(spectra))
+		for j, spectrum := range spectra {
+			for k, x := range spectrum {
+				mag := math.Hypot(real(x), imag(x))
+				if mag == 0 {
+					spectrum[k] = complex(magnitudes[j][k], 0)
+				} else {
+					scale := magnitudes[j][k] / mag
+					spectrum[k] = complex(real(x)*scale, imag(x)*scale)
+				}
+			}
+		}
+		signal = stft.Inverse(spectra)
+	}
+
+	if a := options.PreEmphasis; a != 0 {
+		for i := 1; i < len(signal); i++ {
+			signal[i] += a * signal[i-1]
+		}
+	}
+
+	outRate := intOrDefault(inv.SampleRate, sampleRate)
+	if outRate == layout.SampleRate {
+		return signal
+	}
+	ratio := float64(outRate) / float64(layout.SampleRate)
+	return collectSamples(Resample(&SliceSource{Slice: signal}, ratio, inv.Resampling))
+}
+
+// invertLogBanks maps log Mel bank energies to power
+// spectra using a regularized pseudo-inverse of the
+// filter bank.
+func invertLogBanks(banks [][]float64, sampleRate int, options *Options) [][]float64 {
+	layout := NewFrameLayout(sampleRate, options)
+	bank := NewFilterBank(layout.FFTSize, layout.SampleRate,
+		intOrDefault(options.MelCount, DefaultMelCount),
+		floatOrDefault(options.LowFreq, DefaultLowFreq),
+		floatOrDefault(options.HighFreq, DefaultHighFreq),
+		&FilterBankOptions{
+			Scale:      options.MelScale,
+			Fractional: options.FractionalFilters,
+			Norm:       options.FilterNorm,
+		})
+
+	// The minimum-norm solution of W*p = b is
+	// p = W'*inv(W*W')*b, where W*W' is small.
+	n := len(bank.Weights)
+	gram := make([][]float64, n)
+	var trace float64
+	for i, row1 := range bank.Weights {
+		gram[i] = make([]float64, n)
+		for j, row2 := range bank.Weights {
+			for k, x := range row1 {
+				gram[i][j] += x * row2[k]
+			}
+		}
+		trace += gram[i][i]
+	}
+	ridge := invertRidge * trace / float64(n)
+	for i := range gram {
+		gram[i][i] += ridge
+	}
+	chol := cholesky(gram)
+
+	res := make([][]float64, len(banks))
+	for i, logBanks := range banks {
+		energies := make([]float64, n)
+		for j, x := range logBanks {
+			energies[j] = math.Exp(x)
+		}
+		dual := choleskySolve(chol, energies)
+		power := make([]float64, layout.FFTSize/2+1)
+		for j, row := range bank.Weights {
+			for k, w := range row {
+				power[k] += w * dual[j]
+			}
+		}
+		for k, x := range power {
+			power[k] = math.Max(x, 0)
+		}
+		res[i] = power
+	}
+	return res
+}
+
+// spectrumEnergy computes the energy of the frame which
+// produced a power spectrum, using Parseval's theorem.
+func spectrumEnergy(power []float64, fftSize int) float64 {
+	var res float64
+	for i, x := range power {
+		if i == 0 || (fftSize%2 == 0 && i == len(power)-1) {
+			res += x
+		} else {
+			res += 2 * x
+		}
+	}
+	return res
+}
+
+// shortTimeFFT computes short-time Fourier transforms
+// and their least-squares inverses.
+type shortTimeFFT struct {
+	layout FrameLayout
+	plan   *FFTPlan
+	window []float64
+}
+
+// Forward computes the spectra of the first frameCount
+// frames of a signal.
+func (s *shortTimeFFT) Forward(signal []float64, frameCount int) [][]complex128 {
+	res := make([][]complex128, frameCount)
+	frame := make([]float64, s.layout.FFTSize)
+	for i := range res {
+		start := i * s.layout.Step
+		for j, w := range s.window {
+			frame[j] = signal[start+j] * w
+		}
+		res[i] = make([]complex128, s.layout.FFTSize/2+1)
+		s.plan.TransformReal(frame, res[i])
+	}
+	return res
+}
+
+// Inverse finds the signal whose windowed frames best
+// match the given spectra in the least-squares sense.
+func (s *shortTimeFFT) Inverse(spectra [][]complex128) []float64 {
+	if len(spectra) == 0 {
+		return nil
+	}
+	size := s.layout.FFTSize
+	signal := make([]float64, (len(spectra)-1)*s.layout.Step+s.layout.FrameSize)
+	norms := make([]float64, len(signal))
+	full := make([]complex128, size)
+	for i, spectrum := range spectra {
+		for k := range full {
+			if k < len(spectrum) {
+				full[k] = spectrum[k]
+			} else {
+				full[k] = conj(spectrum[size-k])
+			}
+		}
+		s.plan.Inverse(full, full)
+		start := i * s.layout.Step
+		for j, w := range s.window {
+			signal[start+j] += real(full[j]) * w
+			norms[start+j] += w * w
+		}
+	}
+	for i, n := range norms {
+		if n > 1e-8 {
+			signal[i] /= n
+		}
+	}
+	return signal
+}
+
+// cholesky computes the lower-triangular Cholesky factor
+// of a symmetric, positive-definite matrix.
+func cholesky(mat [][]float64) [][]float64 {
+	res := make([][]float64, len(mat))
+	for i := range res {
+		res[i] = make([]float64, i+1)
+		for j := 0; j <= i; j++ {
+			sum := mat[i][j]
+			for k := 0; k < j; k++ {
+				sum -= res[i][k] * res[j][k]
+			}
+			if i == j {
+				res[i][i] = math.Sqrt(math.Max(sum, 0))
+			} else if res[j][j] != 0 {
+				res[i][j] = sum / res[j][j]
+			}
+		}
+	}
+	return res
+}
+
+// choleskySolve solves L*L'*x = b for x, given L from
+// cholesky.
+func choleskySolve(l [][]float64, b []float64) []float64 {
+	y := make([]float64, len(b))
+	for i := range y {
+		sum := b[i]
+		for k := 0; k < i; k++ {
+			sum -= l[i][k] * y[k]
+		}
+		y[i] = sum / l[i][i]
+	}
+	x := make([]float64, len(b))
+	for i := len(x) - 1; i >= 0; i-- {
+		sum := y[i]
+		for k := i + 1; k < len(x); k++ {
+			sum -= l[k][i] * x[k]
+		}
+		x[i] = sum / l[i][i]
+	}
+	return x
+}
+
+// collectSamples reads a Source until it returns an
+// error.
+func collectSamples(s Source) []float64 {
+	var res []float64
+	buf := make([]float64, 1024)
+	for {
+		n, err := s.ReadSamples(buf)
+		res = append(res, buf[:n]...)
+		if err != nil {
+			return res
+		}
+	}
+}
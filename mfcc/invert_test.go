@@ -0,0 +1,94 @@
+package mfcc
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestInvertPowerSpectrum(t *testing.T) {
+	rand.Seed(123)
+	signal := chirp(200, 3000, 8000, 4000)
+	opts := &Options{NativeSampleRate: true, WindowFunc: HannWindow}
+	powers := readAllCoeffs(t, PowerSpectrum(&SliceSource{Slice: signal}, 8000, opts))
+
+	var lastError float64
+	for i, iters := range []int{1, 10, 50} {
+		audio := InvertPowerSpectrum(powers, 8000, opts, &InvertOptions{Iterations: iters})
+		if len(audio) < len(signal) {
+			t.Fatalf("expected at least %d samples but got %d", len(signal), len(audio))
+		}
+		actual := readAllCoeffs(t, PowerSpectrum(&SliceSource{Slice: audio[:len(signal)]},
+			8000, opts))
+		err := spectralConvergence(powers, actual)
+		if i > 0 && err >= lastError {
+			t.Errorf("%d iterations: error %f did not improve on %f", iters, err, lastError)
+		}
+		lastError = err
+	}
+	if lastError > 0.2 {
+		t.Errorf("unexpectedly large error %f", lastError)
+	}
+}
+
+func TestInvertMFCC(t *testing.T) {
+	signal := chirp(300, 3000, 16000, 16000)
+	optionSets := []*Options{
+		{WindowFunc: HammingWindow, KeepCount: 26, FractionalFilters: true},
+		{
+			NativeSampleRate: true,
+			WindowFunc:       PoveyWindow,
+			PreEmphasis:      0.97,
+			Lifter:           22,
+			DCTNorm:          OrthoDCTNorm,
+			KeepCount:        26,
+			Energy:           AppendEnergy,
+			HighFreq:         7000,
+		},
+	}
+	for i, opts := range optionSets {
+		coeffs := readAllCoeffs(t, MFCC(&SliceSource{Slice: signal}, 16000, opts))
+		audio := InvertMFCC(coeffs, 16000, opts, &InvertOptions{
+			SampleRate: 8000,
+			Resampling: MediumQuality,
+		})
+		if math.Abs(float64(len(audio)-len(signal)/2)) > 400 {
+			t.Errorf("options %d: expected about %d samples but got %d", i, len(signal)/2,
+				len(audio))
+		}
+
+		// The reconstruction should have similar Mel bank
+		// energies to the original audio.
+		bankOpts := &Options{NativeSampleRate: true, HighFreq: 3800}
+		expected := readAllCoeffs(t, LogFilterBank(&SliceSource{Slice: signal}, 16000,
+			bankOpts))
+		actual := readAllCoeffs(t, LogFilterBank(&SliceSource{Slice: audio}, 8000,
+			bankOpts))
+		var diff float64
+		var count int
+		for j := 5; j < len(expected)-5 && j < len(actual)-5; j++ {
+			for k, x := range expected[j] {
+				diff += math.Abs(x - actual[j][k])
+				count++
+			}
+		}
+		if diff/float64(count) > 1.5 {
+			t.Errorf("options %d: mean log energy error %f is too large", i,
+				diff/float64(count))
+		}
+	}
+}
+
+// spectralConvergence measures the relative error between
+// two sequences of power spectra.
+func spectralConvergence(expected, actual [][]float64) float64 {
+	var num, denom float64
+	for i, e := range expected {
+		for j, x := range e {
+			diff := math.Sqrt(x) - math.Sqrt(actual[i][j])
+			num += diff * diff
+			denom += x
+		}
+	}
+	return math.Sqrt(num / denom)
+}
@@ -0,0 +1,65 @@
+package mfcc
+
+import (
+	"errors"
+	"io"
+)
+
+// JoinCoeffs generates a CoeffSource which concatenates
+// the vectors of several CoeffSources, such as MFCCs and
+// pitch features computed on the same frames.
+//
+// The joined source ends when the first source ends,
+// and its frames have the same timing as the first
+// source's frames.
+// If another source ends before the first one, its last
+// vector is repeated for the remaining frames.
+// If it ends before producing any vector, an error is
+// returned rather than emitting shorter vectors.
+// Errors other than io.EOF from the other sources are
+// returned right away.
+func JoinCoeffs(first CoeffSource, others ...CoeffSource) FrameSource {
+	return &joinedSource{
+		First:  Frames(first),
+		Others: others,
+		last:   make([][]float64, len(others)),
+		done:   make([]bool, len(others)),
+	}
+}
+
+type joinedSource struct {
+	First  FrameSource
+	Others []CoeffSource
+
+	last [][]float64
+	done []bool
+}
+
+func (j *joinedSource) NextCoeffs() ([]float64, error) {
+	return coeffsOf(j.NextFrame())
+}
+
+func (j *joinedSource) NextFrame() (*Frame, error) {
+	frame, err := j.First.NextFrame()
+	if err != nil {
+		return nil, err
+	}
+	res := append([]float64{}, frame.Coeffs...)
+	for i, source := range j.Others {
+		if !j.done[i] {
+			next, err := source.NextCoeffs()
+			if err == io.EOF {
+				if j.last[i] == nil {
+					return nil, errors.New("joined source ended without any vectors")
+				}
+				j.done[i] = true
+			} else if err != nil {
+				return nil, err
+			} else {
+				j.last[i] = next
+			}
+		}
+		res = append(res, j.last[i]...)
+	}
+	return frame.withCoeffs(res), nil
+}
@@ -0,0 +1,31 @@
+package mfcc
+
+import "testing"
+
+func TestJoinCoeffs(t *testing.T) {
+	first := &sliceCoeffSource{vecs: [][]float64{{1, 2}, {3, 4}, {5, 6}}}
+	second := &sliceCoeffSource{vecs: [][]float64{{7}, {8}}}
+	third := &sliceCoeffSource{vecs: [][]float64{{9, 10}, {11, 12}, {13, 14}, {15, 16}}}
+	actual := readAllCoeffs(t, JoinCoeffs(first, second, third))
+	expected := [][]float64{
+		{1, 2, 7, 9, 10},
+		{3, 4, 8, 11, 12},
+		{5, 6, 8, 13, 14},
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d frames but got %d", len(expected), len(actual))
+	}
+	for i, x := range expected {
+		if len(actual[i]) != len(x) || !slicesClose(actual[i], x) {
+			t.Errorf("frame %d: expected %v got %v", i, x, actual[i])
+		}
+	}
+}
+
+func TestJoinCoeffsEmpty(t *testing.T) {
+	first := &sliceCoeffSource{vecs: [][]float64{{1, 2}, {3, 4}}}
+	joined := JoinCoeffs(first, &sliceCoeffSource{})
+	if vec, err := joined.NextCoeffs(); err == nil {
+		t.Errorf("expected error but got %v", vec)
+	}
+}
@@ -2,14 +2,109 @@ package mfcc
 
 import "math"
 
+// MelScale is a formula for converting frequencies to
+// and from Mels.
+type MelScale int
+
+const (
+	// HTKMelScale is m = 1125*ln(1+f/700).
+	// This is the scale MFCC uses by default.
+	HTKMelScale MelScale = iota
+
+	// SlaneyMelScale is the scale from Slaney's Auditory
+	// Toolbox, which is linear below 1000Hz and
+	// logarithmic above it.
+	// This is the default scale in librosa.
+	SlaneyMelScale
+
+	// OShaughnessyMelScale is m = 2595*log10(1+f/700).
+	// It places filters the same way as HTKMelScale, but
+	// its Mels have slightly different units.
+	OShaughnessyMelScale
+)
+
+// Constants for SlaneyMelScale.
+const (
+	slaneyLinearStep = 200.0 / 3
+	slaneyMinLogHz   = 1000.0
+	slaneyMinLogMel  = slaneyMinLogHz / slaneyLinearStep
+)
+
+var slaneyLogStep = math.Log(6.4) / 27
+
+// ToMels converts a frequency in Hertz to Mels.
+func (m MelScale) ToMels(h float64) float64 {
+	switch m {
+	case HTKMelScale:
+		return hertzToMels(h)
+	case SlaneyMelScale:
+		if h < slaneyMinLogHz {
+			return h / slaneyLinearStep
+		}
+		return slaneyMinLogMel + math.Log(h/slaneyMinLogHz)/slaneyLogStep
+	case OShaughnessyMelScale:
+		return 2595 * math.Log10(1+h/700)
+	default:
+		panic("unknown Mel scale")
+	}
+}
+
+// FromHertz is the same as ToMels.
+// It allows a MelScale to be used as a FrequencyScale.
+func (m MelScale) FromHertz(h float64) float64 {
+	return m.ToMels(h)
+}
+
+// ToHertz converts Mels to a frequency in Hertz.
+func (m MelScale) ToHertz(mels float64) float64 {
+	switch m {
+	case HTKMelScale:
+		return melsToHertz(mels)
+	case SlaneyMelScale:
+		if mels < slaneyMinLogMel {
+			return mels * slaneyLinearStep
+		}
+		return slaneyMinLogHz * math.Exp(slaneyLogStep*(mels-slaneyMinLogMel))
+	case OShaughnessyMelScale:
+		return 700 * (math.Pow(10, mels/2595) - 1)
+	default:
+		panic("unknown Mel scale")
+	}
+}
+
+// FilterNorm specifies how Mel filters are scaled.
+type FilterNorm int
+
+const (
+	// NoFilterNorm gives every filter a peak of 1.
+	NoFilterNorm FilterNorm = iota
+
+	// SlaneyFilterNorm divides every filter by half of
+	// its width in Hertz, so that each filter has roughly
+	// the same area.
+	// This matches librosa's norm="slaney".
+	SlaneyFilterNorm
+)
+
 type melBin struct {
 	startIdx  int
 	middleIdx int
 	endIdx    int
+
+	// weights, if non-nil, overrides the triangle given
+	// by the indices, with weights[i] applying to the
+	// power at index startIdx+i.
+	weights []float64
 }
 
 func (m melBin) Apply(powers []float64) float64 {
 	var res float64
+	if m.weights != nil {
+		for i, w := range m.weights {
+			res += w * powers[m.startIdx+i]
+		}
+		return res
+	}
 	for i := m.startIdx + 1; i < m.middleIdx; i++ {
 		dist := float64(i-m.startIdx) / float64(m.middleIdx-m.startIdx)
 		res += dist * powers[i]
@@ -21,40 +116,139 @@ func (m melBin) Apply(powers []float64) float64 {
 	return res
 }
 
+// denseWeights computes the weight of every one of the
+// size entries of a power spectrum.
+func (m melBin) denseWeights(size int) []float64 {
+	res := make([]float64, size)
+	weights := m.weights
+	if weights == nil {
+		weights = m.triangleWeights()
+	}
+	copy(res[m.startIdx:], weights)
+	return res
+}
+
+// triangleWeights computes the weights of the triangle
+// given by the indices, in the format of m.weights.
+func (m melBin) triangleWeights() []float64 {
+	res := make([]float64, m.endIdx-m.startIdx)
+	for i := m.startIdx + 1; i < m.middleIdx; i++ {
+		res[i-m.startIdx] = float64(i-m.startIdx) / float64(m.middleIdx-m.startIdx)
+	}
+	for i := m.middleIdx; i < m.endIdx; i++ {
+		dist := float64(i-m.middleIdx) / float64(m.endIdx-m.middleIdx)
+		res[i-m.startIdx] = 1 - dist
+	}
+	return res
+}
+
 type melBinner []melBin
 
-func newMelBinner(fftSize, sampleRate, binCount int, minFreq, maxFreq float64) melBinner {
+func newMelBinner(fftSize, sampleRate, binCount int, minFreq, maxFreq float64,
+	opts FilterBankOptions) melBinner {
+	points := filterPoints(sampleRate, binCount, minFreq, maxFreq, opts.scale())
+	return pointsMelBinner(fftSize, sampleRate, points, opts)
+}
+
+// filterPoints computes the corner frequencies of
+// triangular filters which are evenly spaced on a scale.
+// Filter i starts at point i, peaks at point i+1, and
+// ends at point i+2.
+func filterPoints(sampleRate, binCount int, minFreq, maxFreq float64,
+	scale FrequencyScale) []float64 {
 	if hardMax := float64(sampleRate) / 2; maxFreq > hardMax {
 		maxFreq = hardMax
 	}
 
-	minMels, maxMels := hertzToMels(minFreq), hertzToMels(maxFreq)
+	minScaled, maxScaled := scale.FromHertz(minFreq), scale.FromHertz(maxFreq)
 
 	points := make([]float64, binCount+2)
-	points[0] = minMels
-	for i := 1; i <= binCount; i++ {
-		points[i] = minMels + float64(i)*(maxMels-minMels)/float64(binCount+1)
+	for i := range points {
+		scaled := maxScaled
+		if i <= binCount {
+			scaled = minScaled + float64(i)*(maxScaled-minScaled)/float64(binCount+1)
+		}
+		points[i] = scale.ToHertz(scaled)
+	}
+	return points
+}
+
+// pointsMelBinner creates the triangular filters for the
+// corner frequencies from filterPoints.
+func pointsMelBinner(fftSize, sampleRate int, points []float64,
+	opts FilterBankOptions) melBinner {
+	binCount := len(points) - 2
+
+	var res melBinner
+	if opts.Fractional {
+		res = fractionalMelBinner(fftSize, sampleRate, points)
+	} else {
+		fftPoints := make([]int, len(points))
+		for i, h := range points {
+			fftPoints[i] = hertzToBin(h, fftSize, sampleRate)
+		}
+		res = make(melBinner, binCount)
+		for i := range res {
+			res[i] = melBin{
+				startIdx:  fftPoints[i],
+				middleIdx: fftPoints[i+1],
+				endIdx:    fftPoints[i+2],
+			}
+		}
 	}
-	points[binCount+1] = maxMels
 
-	fftPoints := make([]int, len(points))
-	for i, m := range points {
-		fftPoints[i] = hertzToBin(melsToHertz(m), fftSize, sampleRate)
+	if opts.Norm == SlaneyFilterNorm {
+		for i, bin := range res {
+			if bin.weights == nil {
+				bin.weights = bin.triangleWeights()
+			}
+			scale := 2 / (points[i+2] - points[i])
+			for j := range bin.weights {
+				bin.weights[j] *= scale
+			}
+			res[i] = bin
+		}
 	}
 
-	res := make(melBinner, binCount)
+	return res
+}
+
+// fractionalMelBinner creates triangular filters whose
+// corners are exactly at the given frequencies, rather
+// than at the nearest FFT bins.
+func fractionalMelBinner(fftSize, sampleRate int, points []float64) melBinner {
+	freqScale := float64(sampleRate) / float64(fftSize)
+	res := make(melBinner, len(points)-2)
 	for i := range res {
-		res[i] = melBin{
-			startIdx:  fftPoints[i],
-			middleIdx: fftPoints[i+1],
-			endIdx:    fftPoints[i+2],
+		start, middle, end := points[i], points[i+1], points[i+2]
+		var bin melBin
+		for j := 0; j <= fftSize/2; j++ {
+			freq := float64(j) * freqScale
+			w := math.Min((freq-start)/(middle-start), (end-freq)/(end-middle))
+			if w <= 0 {
+				if bin.weights != nil {
+					break
+				}
+				continue
+			}
+			if bin.weights == nil {
+				bin.startIdx = j
+			}
+			bin.weights = append(bin.weights, w)
 		}
+		if bin.weights == nil {
+			bin.weights = []float64{}
+		}
+		res[i] = bin
 	}
 	return res
 }
 
 func (m melBinner) Apply(f fftBins) []float64 {
-	powers := f.powerSpectrum()
+	return m.applyPowers(f.powerSpectrum())
+}
+
+func (m melBinner) applyPowers(powers []float64) []float64 {
 	res := make([]float64, len(m))
 	for i, b := range m {
 		res[i] = b.Apply(powers)
@@ -73,7 +267,7 @@ func melsToHertz(m float64) float64 {
 func hertzToBin(h float64, fftSize, sampleRate int) int {
 	freqScale := float64(sampleRate) / float64(fftSize)
 	bin := h / freqScale
-	bin = math.Min(bin, float64(fftSize)/2)
+	bin = math.Min(bin, float64(fftSize/2))
 	floorFreq := math.Floor(bin) * freqScale
 	ceilFreq := math.Ceil(bin) * freqScale
 	if math.Abs(floorFreq-h) < math.Abs(ceilFreq-h) {
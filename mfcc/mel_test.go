@@ -16,7 +16,7 @@ func TestMelBin(t *testing.T) {
 }
 
 func TestMelBinner(t *testing.T) {
-	binner := newMelBinner(8, 16, 2, 2, 8)
+	binner := newMelBinner(8, 16, 2, 2, 8, FilterBankOptions{})
 	actual := binner.Apply(fftBins{
 		Cos: []float64{2, 3, 4, 5, 6},
 		Sin: []float64{0, 0, 0},
@@ -28,3 +28,143 @@ func TestMelBinner(t *testing.T) {
 		t.Errorf("expected %v got %v", expected, actual)
 	}
 }
+
+func TestMelScales(t *testing.T) {
+	cases := []struct {
+		Scale MelScale
+		Hertz float64
+		Mels  float64
+	}{
+		{HTKMelScale, 700, 1125 * math.Log(2)},
+		{SlaneyMelScale, 500, 7.5},
+		{SlaneyMelScale, 1000, 15},
+		{SlaneyMelScale, 4000, 35.16376031},
+		{OShaughnessyMelScale, 700, 2595 * math.Log10(2)},
+		{OShaughnessyMelScale, 1000, 999.9855371},
+	}
+	for _, c := range cases {
+		if actual := c.Scale.ToMels(c.Hertz); math.Abs(actual-c.Mels) > 1e-6 {
+			t.Errorf("scale %d: expected %f Hz -> %f mels but got %f", c.Scale, c.Hertz,
+				c.Mels, actual)
+		}
+		if actual := c.Scale.ToHertz(c.Mels); math.Abs(actual-c.Hertz) > 1e-4 {
+			t.Errorf("scale %d: expected %f mels -> %f Hz but got %f", c.Scale, c.Mels,
+				c.Hertz, actual)
+		}
+	}
+}
+
+func TestMelBinnerGolden(t *testing.T) {
+	// These matrices come from librosa.filters.mel with
+	// sr=8000, n_fft=16, n_mels=4, fmin=0 and fmax=4000.
+	cases := []struct {
+		Shape    FilterBankOptions
+		Expected [][]float64
+	}{
+		{
+			// htk=False, norm="slaney"
+			Shape: FilterBankOptions{
+				Scale:      SlaneyMelScale,
+				Fractional: true,
+				Norm:       SlaneyFilterNorm,
+			},
+			Expected: [][]float64{
+				{0, 0.001991171759, 0, 0, 0, 0, 0, 0, 0},
+				{0, 0.0001263102388, 0.001698042666, 6.795750989e-05, 0, 0, 0, 0, 0},
+				{0, 0, 0.0001397665837, 0.001261493716, 0.0006453405721, 0, 0, 0, 0},
+				{0, 0, 0, 0, 0.0004087825201, 0.0007890777448, 0.0005260518298,
+					0.0002630259149, 0},
+			},
+		},
+		{
+			// htk=True, norm=None
+			Shape: FilterBankOptions{
+				Scale:      OShaughnessyMelScale,
+				Fractional: true,
+			},
+			Expected: [][]float64{
+				{0, 0.6303523003, 0, 0, 0, 0, 0, 0, 0},
+				{0, 0.3696476997, 0.7112603714, 0, 0, 0, 0, 0, 0},
+				{0, 0, 0.2887396286, 0.9944054956, 0.5028195191, 0.01123354253, 0, 0, 0},
+				{0, 0, 0, 0.005594504402, 0.4971804809, 0.9887664575, 0.6717837856,
+					0.3358918928, 0},
+			},
+		},
+	}
+	for i, c := range cases {
+		binner := newMelBinner(16, 8000, 4, 0, 4000, c.Shape)
+		actual := melBinnerMatrix(binner, 9)
+		for j, row := range c.Expected {
+			for k, x := range row {
+				if math.Abs(actual[j][k]-x) > 1e-8*math.Max(1, math.Abs(x)) {
+					t.Errorf("case %d: filter %d: expected %v got %v", i, j, row, actual[j])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestMelBinnerSlaneyNorm(t *testing.T) {
+	plain := newMelBinner(512, 16000, 40, 20, 8000, FilterBankOptions{})
+	normed := newMelBinner(512, 16000, 40, 20, 8000, FilterBankOptions{Norm: SlaneyFilterNorm})
+	plainMat := melBinnerMatrix(plain, 257)
+	normedMat := melBinnerMatrix(normed, 257)
+	minMels, maxMels := hertzToMels(20), hertzToMels(8000)
+	for i := range plainMat {
+		lower := melsToHertz(minMels + float64(i)*(maxMels-minMels)/41)
+		upper := melsToHertz(minMels + float64(i+2)*(maxMels-minMels)/41)
+		for j, x := range plainMat[i] {
+			expected := x * 2 / (upper - lower)
+			if math.Abs(normedMat[i][j]-expected) > 1e-12 {
+				t.Fatalf("filter %d bin %d: expected %g got %g", i, j, expected,
+					normedMat[i][j])
+			}
+		}
+	}
+}
+
+func TestMelBinnerFractional(t *testing.T) {
+	// With a small FFT, rounding gives the lowest filters
+	// no weight at all.
+	rounded := melBinnerMatrix(newMelBinner(128, 16000, 23, 20, 8000, FilterBankOptions{}), 65)
+	fractional := melBinnerMatrix(newMelBinner(128, 16000, 23, 20, 8000,
+		FilterBankOptions{Fractional: true}), 65)
+	var emptyRounded bool
+	for i := range rounded {
+		var roundedSum, fractionalSum float64
+		for j := range rounded[i] {
+			roundedSum += rounded[i][j]
+			fractionalSum += fractional[i][j]
+			if fractional[i][j] < 0 || fractional[i][j] > 1 {
+				t.Errorf("filter %d: bad weight %f", i, fractional[i][j])
+			}
+		}
+		if roundedSum == 0 {
+			emptyRounded = true
+		}
+		if fractionalSum == 0 {
+			t.Errorf("filter %d: fractional filter is empty", i)
+		}
+	}
+	if !emptyRounded {
+		t.Error("expected some rounded filters to be empty")
+	}
+}
+
+// melBinnerMatrix computes the weight of every power
+// spectrum bin in every filter.
+func melBinnerMatrix(m melBinner, size int) [][]float64 {
+	res := make([][]float64, len(m))
+	for i := range res {
+		res[i] = make([]float64, size)
+	}
+	for j := 0; j < size; j++ {
+		unit := make([]float64, size)
+		unit[j] = 1
+		for i, x := range m.applyPowers(unit) {
+			res[i][j] = x
+		}
+	}
+	return res
+}
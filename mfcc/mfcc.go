@@ -19,6 +19,25 @@ const (
 	DefaultHighFreq  = 8000
 	DefaultMelCount  = 26
 	DefaultKeepCount = 13
+
+	DefaultLogFloor = 1e-10
+)
+
+// EnergyMode determines how the log energy of each frame
+// is included in a vector of MFCCs.
+type EnergyMode int
+
+const (
+	// NoEnergy excludes the log energy.
+	NoEnergy EnergyMode = iota
+
+	// AppendEnergy appends the log energy after the last
+	// cepstral coefficient.
+	AppendEnergy
+
+	// EnergyAsC0 replaces the zeroth cepstral coefficient
+	// with the log energy.
+	EnergyAsC0
 )
 
 // Options stores all of the configuration options for
@@ -40,13 +59,27 @@ type Options struct {
 
 	// FFTSize is the number of FFT bins to compute for
 	// each window.
-	// This must be a power of 2.
-	// If this is 0, DefaultFFTSize is used.
+	// Powers of 2 are fastest, but any size may be used.
+	// If this is 0, DefaultFFTSize is used, unless
+	// NativeSampleRate is set.
 	//
 	// It may be noted that the FFT size influences the
-	// upsampling/downsampling behavior of the converter.
+	// upsampling/downsampling behavior of the converter,
+	// unless NativeSampleRate is set.
 	FFTSize int
 
+	// NativeSampleRate can be set to split the audio into
+	// frames at its original sample rate, rather than
+	// resampling it so that each frame has FFTSize
+	// samples.
+	//
+	// In this mode, every frame contains Window worth of
+	// samples and is zero-padded up to FFTSize before the
+	// FFT is applied.
+	// If FFTSize is 0 or is smaller than a frame, the
+	// smallest power of 2 which fits a frame is used.
+	NativeSampleRate bool
+
 	// LowFreq is the minimum frequency for Mel banks.
 	// If this is 0, DefaultLowFreq is used.
 	LowFreq float64
@@ -61,10 +94,84 @@ type Options struct {
 	// If this is 0, DefaultMelCount is used.
 	MelCount int
 
+	// MelScale is the formula used to space the Mel
+	// banks between LowFreq and HighFreq.
+	// The default is HTKMelScale.
+	MelScale MelScale
+
+	// FractionalFilters can be set to place the corners
+	// of each triangular Mel filter at their exact
+	// frequencies, rather than rounding them to the
+	// nearest FFT bins.
+	// This avoids degenerate filters at low frequencies
+	// when the FFT is small.
+	FractionalFilters bool
+
+	// FilterNorm determines how the Mel filters are
+	// scaled.
+	// The default, NoFilterNorm, gives each filter a peak
+	// of 1.
+	FilterNorm FilterNorm
+
 	// KeepCount is the number of MFCCs to keep after the
 	// discrete cosine transform is complete.
 	// If this is 0, DefaultKeepCount is used.
 	KeepCount int
+
+	// WindowFunc is the analysis window applied to each
+	// frame before the FFT, such as HammingWindow.
+	// If this is nil, a rectangular window is used.
+	WindowFunc WindowFunc
+
+	// PreEmphasis is the coefficient a of the filter
+	// y[n] = x[n] - a*x[n-1], which is applied to the
+	// audio stream before it is split into frames.
+	// A typical value is 0.97.
+	// If this is 0, no pre-emphasis is performed.
+	PreEmphasis float64
+
+	// RemoveDC can be set to subtract the mean of each
+	// frame from its samples before windowing.
+	RemoveDC bool
+
+	// Resampling is the algorithm used to convert the
+	// audio to the sample rate implied by Window and
+	// FFTSize.
+	// It is ignored if NativeSampleRate is set.
+	// The default, LinearQuality, does not band-limit the
+	// signal, so it may alias when downsampling.
+	Resampling ResampleQuality
+
+	// Energy determines whether and how the log energy of
+	// each frame is included in the MFCCs.
+	// It is ignored by LogFilterBank and PowerSpectrum.
+	Energy EnergyMode
+
+	// RawEnergy can be set to compute the frame energy
+	// before the analysis window is applied, rather than
+	// after it.
+	RawEnergy bool
+
+	// Lifter is the parameter L of the sinusoidal lifter
+	// 1 + (L/2)*sin(pi*n/L), which scales the n-th MFCC.
+	// A typical value is 22.
+	// If this is 0, no liftering is performed.
+	// It is ignored by LogFilterBank and PowerSpectrum.
+	Lifter float64
+
+	// LogFloor is the smallest value that Mel bank
+	// energies and frame energies are clipped to before
+	// taking their logarithms.
+	// This prevents silent frames from producing infinite
+	// coefficients.
+	// If this is 0, DefaultLogFloor is used.
+	LogFloor float64
+
+	// DCTNorm is the scaling applied to the DCT which
+	// turns log Mel bank energies into MFCCs.
+	// The default, NoDCTNorm, leaves it unscaled.
+	// It is ignored by LogFilterBank and PowerSpectrum.
+	DCTNorm DCTNorm
 }
 
 // CoeffSource computes MFCCs (or augmented MFCCs) from an
@@ -84,19 +191,130 @@ type CoeffSource interface {
 // After source returns its first error, the last window
 // will be padded with zeroes and used to compute a final
 // batch of MFCCs before returning the error.
-func MFCC(source Source, sampleRate int, options *Options) CoeffSource {
+func MFCC(source Source, sampleRate int, options *Options) FrameSource {
+	return newCoeffChan(source, sampleRate, options, cepstrumStage)
+}
+
+// LogFilterBank generates a CoeffSource that computes the
+// logarithms of the Mel bank energies of the given Source,
+// commonly known as "fbank" features.
+//
+// This performs the same steps as MFCC, but it stops
+// before the discrete cosine transform.
+// As a result, KeepCount is ignored and there is one
+// coefficient per Mel bank.
+func LogFilterBank(source Source, sampleRate int, options *Options) FrameSource {
+	return newCoeffChan(source, sampleRate, options, filterBankStage)
+}
+
+// PowerSpectrum generates a CoeffSource that computes the
+// power spectrum of each frame of the given Source.
+//
+// This performs the same framing and FFT as MFCC.
+// Each batch of coefficients has FFTSize/2+1 entries,
+// starting at 0Hz and ending at the Nyquist frequency.
+// Mel bank options are ignored.
+func PowerSpectrum(source Source, sampleRate int, options *Options) FrameSource {
+	return newCoeffChan(source, sampleRate, options, powerStage)
+}
+
+func newCoeffChan(source Source, sampleRate int, options *Options,
+	stage coeffStage) *coeffChan {
 	if options == nil {
 		options = &Options{}
 	}
+	layout := NewFrameLayout(sampleRate, options)
 
+	binCount := intOrDefault(options.MelCount, DefaultMelCount)
+	minFreq := floatOrDefault(options.LowFreq, DefaultLowFreq)
+	maxFreq := floatOrDefault(options.HighFreq, DefaultHighFreq)
+
+	resampled := source
+	if !options.NativeSampleRate {
+		resampled = Resample(source, float64(layout.SampleRate)/float64(sampleRate),
+			options.Resampling)
+	}
+	if options.PreEmphasis != 0 {
+		resampled = &preEmphasizer{S: resampled, Coeff: options.PreEmphasis}
+	}
+
+	return &coeffChan{
+		prepared: resampled,
+		windowedSource: &framer{
+			S:    resampled,
+			Size: layout.FrameSize,
+			Step: layout.Step,
+		},
+		frameSize:  layout.FrameSize,
+		step:       layout.Step,
+		sampleRate: layout.SampleRate,
+		fftSize:    layout.FFTSize,
+		plan:       cachedFFTPlan(layout.FFTSize),
+		window:     &windowCache{Func: options.WindowFunc},
+		removeDC:   options.RemoveDC,
+		binner: newMelBinner(layout.FFTSize, layout.SampleRate, binCount,
+			minFreq, maxFreq, FilterBankOptions{
+				Scale:      options.MelScale,
+				Fractional: options.FractionalFilters,
+				Norm:       options.FilterNorm,
+			}),
+		keepCount: intOrDefault(options.KeepCount, DefaultKeepCount),
+		stage:     stage,
+		energy:    options.Energy,
+		rawEnergy: options.RawEnergy,
+		lifter:    options.Lifter,
+		logFloor:  floatOrDefault(options.LogFloor, DefaultLogFloor),
+		dctNorm:   options.DCTNorm,
+	}
+}
+
+// A FrameLayout describes how MFCC and related functions
+// divide audio into frames for spectral analysis.
+type FrameLayout struct {
+	// SampleRate is the rate of the audio once it has
+	// been resampled for framing.
+	SampleRate int
+
+	// FrameSize is the number of samples in each frame.
+	FrameSize int
+
+	// Step is the number of samples between the starts
+	// of consecutive frames.
+	Step int
+
+	// FFTSize is the size of the FFT applied to each
+	// zero-padded frame.
+	FFTSize int
+}
+
+// NewFrameLayout computes the FrameLayout which MFCC uses
+// for audio with the given sample rate.
+func NewFrameLayout(sampleRate int, options *Options) FrameLayout {
 	windowTime := options.Window
 	if windowTime == 0 {
 		windowTime = DefaultWindow
 	}
 	windowSeconds := float64(windowTime) / float64(time.Second)
 
-	fftSize := intOrDefault(options.FFTSize, DefaultFFTSize)
-	newSampleRate := int(float64(fftSize)/windowSeconds + 0.5)
+	var res FrameLayout
+	if options.NativeSampleRate {
+		res.SampleRate = sampleRate
+		res.FrameSize = int(windowSeconds*float64(sampleRate) + 0.5)
+		if res.FrameSize < 1 {
+			res.FrameSize = 1
+		}
+		res.FFTSize = options.FFTSize
+		if res.FFTSize < res.FrameSize {
+			res.FFTSize = 1
+			for res.FFTSize < res.FrameSize {
+				res.FFTSize <<= 1
+			}
+		}
+	} else {
+		res.FFTSize = intOrDefault(options.FFTSize, DefaultFFTSize)
+		res.FrameSize = res.FFTSize
+		res.SampleRate = int(float64(res.FFTSize)/windowSeconds + 0.5)
+	}
 
 	overlapTime := options.Overlap
 	if options.DisableOverlap {
@@ -105,68 +323,209 @@ func MFCC(source Source, sampleRate int, options *Options) CoeffSource {
 		overlapTime = DefaultOverlap
 	}
 	overlapSeconds := float64(overlapTime) / float64(time.Second)
-	overlapSamples := int(overlapSeconds*float64(newSampleRate) + 0.5)
-	if overlapSamples >= fftSize {
-		overlapSamples = fftSize - 1
+	overlapSamples := int(overlapSeconds*float64(res.SampleRate) + 0.5)
+	if overlapSamples >= res.FrameSize {
+		overlapSamples = res.FrameSize - 1
 	}
+	res.Step = res.FrameSize - overlapSamples
 
-	binCount := intOrDefault(options.MelCount, DefaultMelCount)
-	minFreq := floatOrDefault(options.LowFreq, DefaultLowFreq)
-	maxFreq := floatOrDefault(options.HighFreq, DefaultHighFreq)
+	return res
+}
 
-	return &coeffChan{
-		windowedSource: &framer{
-			S: &rateChanger{
-				S:     source,
-				Ratio: float64(newSampleRate) / float64(sampleRate),
-			},
-			Size: fftSize,
-			Step: fftSize - overlapSamples,
-		},
-		windowSize: fftSize,
-		binner:     newMelBinner(fftSize, newSampleRate, binCount, minFreq, maxFreq),
-		keepCount:  intOrDefault(options.KeepCount, DefaultKeepCount),
+// FrameCount returns the number of frames which MFCC
+// produces for the given number of samples at the
+// layout's sample rate.
+//
+// Every frame starts Step samples after the previous
+// one, and the last frame may be partial.
+func (f FrameLayout) FrameCount(sampleCount int) int {
+	if sampleCount <= 0 {
+		return 0
+	} else if sampleCount < f.FrameSize {
+		return 1
 	}
+
+	// A frame is produced if the previous one was full
+	// and there is at least one sample left for it.
+	count := 2 + (sampleCount-f.FrameSize)/f.Step
+	if (count-1)*f.Step >= sampleCount {
+		count--
+	}
+	return count
 }
 
+// SampleTime converts a number of samples at the
+// layout's sample rate to a duration, as used for the
+// times of each Frame.
+func (f FrameLayout) SampleTime(samples int) time.Duration {
+	return sampleTime(samples, f.SampleRate)
+}
+
+// coeffStage indicates the step of the MFCC pipeline at
+// which a coeffChan produces its output.
+type coeffStage int
+
+const (
+	powerStage coeffStage = iota
+	filterBankStage
+	cepstrumStage
+)
+
 type coeffChan struct {
+	// prepared is the resampled and pre-emphasized audio,
+	// which is divided into frames by windowedSource.
+	prepared Source
+
 	windowedSource Source
-	windowSize     int
+	frameSize      int
+	step           int
+	sampleRate     int
+	fftSize        int
+	plan           *FFTPlan
+	window         *windowCache
+	removeDC       bool
 	binner         melBinner
 	keepCount      int
+	stage          coeffStage
+	energy         EnergyMode
+	rawEnergy      bool
+	lifter         float64
+	logFloor       float64
+	dctNorm        DCTNorm
 
+	frameIdx  int
 	doneError error
 }
 
 func (c *coeffChan) NextCoeffs() ([]float64, error) {
+	return coeffsOf(c.NextFrame())
+}
+
+func (c *coeffChan) NextFrame() (*Frame, error) {
+	frame, energy, err := c.nextPowers()
+	if err != nil {
+		return nil, err
+	}
+	frame.Coeffs = c.stageOutput(frame.Coeffs, energy)
+	return frame, nil
+}
+
+// stageOutput turns the power spectrum and energy of a
+// frame into the output for the coeffChan's stage.
+func (c *coeffChan) stageOutput(powers []float64, energy float64) []float64 {
+	if c.stage == powerStage {
+		return powers
+	}
+	banks := c.binner.applyPowers(powers)
+	for i, x := range banks {
+		banks[i] = math.Log(math.Max(x, c.logFloor))
+	}
+	if c.stage == filterBankStage {
+		return banks
+	}
+	return c.finishCepstrum(DCT(banks, c.keepCount, c.dctNorm), energy)
+}
+
+// nextPowers reads the next frame and computes its power
+// spectrum and energy.
+// The power spectrum is stored in the Coeffs of the
+// resulting Frame.
+func (c *coeffChan) nextPowers() (frame *Frame, energy float64, err error) {
 	if c.doneError != nil {
-		return nil, c.doneError
+		return nil, 0, c.doneError
 	}
 
-	buf := make([]float64, c.windowSize)
+	buf := make([]float64, c.fftSize)
 	var have int
-	for have < len(buf) && c.doneError == nil {
-		n, err := c.windowedSource.ReadSamples(buf[have:])
+	for have < c.frameSize && c.doneError == nil {
+		n, err := c.windowedSource.ReadSamples(buf[have:c.frameSize])
 		if err != nil {
 			c.doneError = err
 		}
 		have += n
 	}
 	if have == 0 && c.doneError != nil {
-		return nil, c.doneError
+		return nil, 0, c.doneError
 	}
 
+	powers, energy := c.framePowers(buf, have)
+	start := c.frameIdx * c.step
+	frame = &Frame{
+		Index:  c.frameIdx,
+		Start:  sampleTime(start, c.sampleRate),
+		End:    sampleTime(start+have, c.sampleRate),
+		Coeffs: powers,
+	}
+	c.frameIdx++
+	return frame, energy, nil
+}
+
+// framePowers computes the power spectrum and energy of a
+// frame whose have samples are at the start of buf.
+// The buffer must have fftSize entries, and it is used
+// as scratch space.
+//
+// This may be called from multiple goroutines at once.
+func (c *coeffChan) framePowers(buf []float64, have int) (powers []float64, energy float64) {
 	// ReadSamples can use the buffer as scratch space,
 	// just like io.Reader.
 	for i := have; i < len(buf); i++ {
 		buf[i] = 0
 	}
 
-	banks := c.binner.Apply(fft(buf))
-	for i, x := range banks {
-		banks[i] = math.Log(x)
+	if c.removeDC {
+		removeDC(buf[:have])
+	}
+	if c.rawEnergy {
+		energy = frameEnergy(buf)
+	}
+	c.window.Apply(buf[:c.frameSize])
+	if !c.rawEnergy {
+		energy = frameEnergy(buf)
+	}
+
+	powers = make([]float64, c.fftSize/2+1)
+	c.plan.PowerSpectrum(buf, powers)
+	return
+}
+
+// finishCepstrum applies liftering to cepstral
+// coefficients and adds the frame energy to them.
+func (c *coeffChan) finishCepstrum(coeffs []float64, energy float64) []float64 {
+	if c.lifter != 0 {
+		for i := range coeffs {
+			coeffs[i] *= 1 + c.lifter/2*math.Sin(math.Pi*float64(i)/c.lifter)
+		}
+	}
+	logEnergy := math.Log(math.Max(energy, c.logFloor))
+	switch c.energy {
+	case AppendEnergy:
+		coeffs = append(coeffs, logEnergy)
+	case EnergyAsC0:
+		coeffs[0] = logEnergy
+	}
+	return coeffs
+}
+
+func frameEnergy(frame []float64) float64 {
+	var res float64
+	for _, x := range frame {
+		res += x * x
+	}
+	return res
+}
+
+// removeDC subtracts the mean of a frame from each of
+// its samples.
+func removeDC(frame []float64) {
+	var mean float64
+	for _, x := range frame {
+		mean += x
+	}
+	mean /= float64(len(frame))
+	for i := range frame {
+		frame[i] -= mean
 	}
-	return dct(banks, c.keepCount), nil
 }
 
 func intOrDefault(val, def int) int {
@@ -0,0 +1,258 @@
+package mfcc
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRemoveDC(t *testing.T) {
+	frame := []float64{1, 2, 3, 6}
+	removeDC(frame)
+	expected := []float64{-2, -1, 0, 3}
+	if !slicesClose(frame, expected) {
+		t.Errorf("expected %v got %v", expected, frame)
+	}
+}
+
+func TestMFCCStreaming(t *testing.T) {
+	rand.Seed(123)
+	samples := make([]float64, 4000)
+	for i := range samples {
+		samples[i] = rand.NormFloat64() + 0.3
+	}
+	opts := &Options{
+		PreEmphasis: 0.97,
+		RemoveDC:    true,
+		WindowFunc:  HammingWindow,
+	}
+	expected := readAllCoeffs(t, MFCC(&SliceSource{Slice: samples}, 8000, opts))
+	actual := readAllCoeffs(t, MFCC(&sliceSource{vec: samples, buffSize: 3}, 8000, opts))
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d frames but got %d", len(expected), len(actual))
+	}
+	for i, x := range expected {
+		if !slicesClose(actual[i], x) {
+			t.Errorf("frame %d: expected %v got %v", i, x, actual[i])
+		}
+		for _, c := range x {
+			if math.IsNaN(c) || math.IsInf(c, 0) {
+				t.Errorf("frame %d: invalid coefficient %f", i, c)
+			}
+		}
+	}
+}
+
+func readAllCoeffs(t *testing.T, c CoeffSource) [][]float64 {
+	var res [][]float64
+	for {
+		coeffs, err := c.NextCoeffs()
+		if err == io.EOF {
+			return res
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, coeffs)
+	}
+}
+
+func TestFrameLayout(t *testing.T) {
+	optionList := []*Options{
+		&Options{},
+		&Options{NativeSampleRate: true, Window: 25 * time.Millisecond,
+			Overlap: 15 * time.Millisecond},
+		&Options{NativeSampleRate: true, FFTSize: 1024, DisableOverlap: true},
+		&Options{NativeSampleRate: true, FFTSize: 400, Window: 25 * time.Millisecond},
+		&Options{FFTSize: 400},
+	}
+	expected := []FrameLayout{
+		{SampleRate: 25600, FrameSize: 512, Step: 256, FFTSize: 512},
+		{SampleRate: 16000, FrameSize: 400, Step: 160, FFTSize: 512},
+		{SampleRate: 16000, FrameSize: 320, Step: 320, FFTSize: 1024},
+		{SampleRate: 16000, FrameSize: 400, Step: 240, FFTSize: 400},
+		{SampleRate: 20000, FrameSize: 400, Step: 200, FFTSize: 400},
+	}
+	for i, opts := range optionList {
+		actual := NewFrameLayout(16000, opts)
+		if actual != expected[i] {
+			t.Errorf("%d: expected %+v got %+v", i, expected[i], actual)
+		}
+	}
+}
+
+func TestMFCCNativeSampleRate(t *testing.T) {
+	samples := make([]float64, 16000)
+	for i := range samples {
+		samples[i] = math.Sin(float64(i) * 0.3)
+	}
+	for _, fftSize := range []int{0, 400} {
+		source := MFCC(&SliceSource{Slice: samples}, 16000, &Options{
+			NativeSampleRate: true,
+			Window:           25 * time.Millisecond,
+			Overlap:          15 * time.Millisecond,
+			FFTSize:          fftSize,
+		})
+		coeffs := readAllCoeffs(t, source)
+		if expected := 16000/160 - 1; len(coeffs) != expected {
+			t.Errorf("expected %d frames but got %d", expected, len(coeffs))
+		}
+		for i, frame := range coeffs {
+			for _, x := range frame {
+				if math.IsNaN(x) || math.IsInf(x, 0) {
+					t.Fatalf("frame %d: invalid coefficient %f", i, x)
+				}
+			}
+		}
+	}
+}
+
+func TestIntermediateStages(t *testing.T) {
+	rand.Seed(123)
+	samples := make([]float64, 3000)
+	for i := range samples {
+		samples[i] = rand.NormFloat64()
+	}
+	opts := &Options{WindowFunc: HannWindow, PreEmphasis: 0.97}
+	cepstra := readAllCoeffs(t, MFCC(&SliceSource{Slice: samples}, 8000, opts))
+	banks := readAllCoeffs(t, LogFilterBank(&SliceSource{Slice: samples}, 8000, opts))
+	powers := readAllCoeffs(t, PowerSpectrum(&SliceSource{Slice: samples}, 8000, opts))
+	if len(banks) != len(cepstra) || len(powers) != len(cepstra) {
+		t.Fatalf("frame counts differ: %d, %d, %d", len(cepstra), len(banks), len(powers))
+	}
+
+	layout := NewFrameLayout(8000, opts)
+	binner := newMelBinner(layout.FFTSize, layout.SampleRate, DefaultMelCount,
+		DefaultLowFreq, DefaultHighFreq, FilterBankOptions{})
+	for i, power := range powers {
+		if len(power) != layout.FFTSize/2+1 {
+			t.Fatalf("frame %d: bad power spectrum size %d", i, len(power))
+		}
+		expectedBanks := binner.applyPowers(power)
+		for j, x := range expectedBanks {
+			expectedBanks[j] = math.Log(x)
+		}
+		if len(banks[i]) != DefaultMelCount {
+			t.Fatalf("frame %d: bad filter bank size %d", i, len(banks[i]))
+		} else if !slicesClose(banks[i], expectedBanks) {
+			t.Errorf("frame %d: expected banks %v got %v", i, expectedBanks, banks[i])
+		}
+		expectedCepstrum := dct(banks[i], DefaultKeepCount)
+		if !slicesClose(cepstra[i], expectedCepstrum) {
+			t.Errorf("frame %d: expected cepstrum %v got %v", i, expectedCepstrum,
+				cepstra[i])
+		}
+	}
+}
+
+func TestMFCCSilence(t *testing.T) {
+	samples := make([]float64, 2000)
+	for _, opts := range []*Options{nil, &Options{Energy: AppendEnergy}} {
+		coeffs := readAllCoeffs(t, MFCC(&SliceSource{Slice: samples}, 8000, opts))
+		banks := readAllCoeffs(t, LogFilterBank(&SliceSource{Slice: samples}, 8000, opts))
+		for _, frames := range [][][]float64{coeffs, banks} {
+			for i, frame := range frames {
+				for _, x := range frame {
+					if math.IsNaN(x) || math.IsInf(x, 0) {
+						t.Fatalf("frame %d: invalid coefficient %f", i, x)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMFCCEnergyLifter(t *testing.T) {
+	rand.Seed(123)
+	samples := make([]float64, 3000)
+	for i := range samples {
+		samples[i] = rand.NormFloat64()
+	}
+	readFrames := func(opts *Options) [][]float64 {
+		return readAllCoeffs(t, MFCC(&SliceSource{Slice: samples}, 8000, opts))
+	}
+	plain := readFrames(&Options{WindowFunc: HammingWindow})
+	appended := readFrames(&Options{WindowFunc: HammingWindow, Energy: AppendEnergy})
+	rawAppended := readFrames(&Options{WindowFunc: HammingWindow, Energy: AppendEnergy,
+		RawEnergy: true})
+	replaced := readFrames(&Options{WindowFunc: HammingWindow, Energy: EnergyAsC0})
+	liftered := readFrames(&Options{WindowFunc: HammingWindow, Lifter: 22})
+
+	for i, frame := range plain {
+		if len(appended[i]) != len(frame)+1 {
+			t.Fatalf("frame %d: expected %d coefficients but got %d", i, len(frame)+1,
+				len(appended[i]))
+		}
+		energy := appended[i][len(frame)]
+		rawEnergy := rawAppended[i][len(frame)]
+		if !slicesClose(appended[i][:len(frame)], frame) {
+			t.Errorf("frame %d: appending energy changed cepstrum", i)
+		}
+		if rawEnergy <= energy {
+			t.Errorf("frame %d: raw energy %f should exceed windowed energy %f", i,
+				rawEnergy, energy)
+		}
+		if replaced[i][0] != energy || !slicesClose(replaced[i][1:], frame[1:]) {
+			t.Errorf("frame %d: bad C0 replacement %v", i, replaced[i])
+		}
+		for j, x := range frame {
+			expected := x * (1 + 11*math.Sin(math.Pi*float64(j)/22))
+			if math.Abs(liftered[i][j]-expected) > 1e-5 {
+				t.Errorf("frame %d: expected liftered %f got %f", i, expected, liftered[i][j])
+			}
+		}
+	}
+
+	native := readAllCoeffs(t, MFCC(&SliceSource{Slice: samples}, 8000, &Options{
+		NativeSampleRate: true,
+		WindowFunc:       HammingWindow,
+		Energy:           AppendEnergy,
+	}))
+	window := HammingWindow(160)
+	var expected float64
+	for i, w := range window {
+		expected += samples[i] * samples[i] * w * w
+	}
+	expected = math.Log(expected)
+	if actual := native[0][len(native[0])-1]; math.Abs(actual-expected) > 1e-8 {
+		t.Errorf("expected energy %f got %f", expected, actual)
+	}
+}
+
+func TestMFCCDCTNorm(t *testing.T) {
+	rand.Seed(123)
+	samples := make([]float64, 3000)
+	for i := range samples {
+		samples[i] = rand.NormFloat64()
+	}
+	banks := readAllCoeffs(t, LogFilterBank(&SliceSource{Slice: samples}, 8000, nil))
+	ortho := readAllCoeffs(t, MFCC(&SliceSource{Slice: samples}, 8000, &Options{
+		DCTNorm: OrthoDCTNorm,
+	}))
+	for i, frame := range ortho {
+		expected := DCT(banks[i], DefaultKeepCount, OrthoDCTNorm)
+		if !slicesClose(frame, expected) {
+			t.Errorf("frame %d: expected %v got %v", i, expected, frame)
+		}
+	}
+}
+
+func TestFrameCount(t *testing.T) {
+	optionList := []*Options{
+		{NativeSampleRate: true},
+		{NativeSampleRate: true, DisableOverlap: true},
+		{NativeSampleRate: true, Window: 25 * time.Millisecond},
+	}
+	for i, opts := range optionList {
+		layout := NewFrameLayout(8000, opts)
+		for _, count := range []int{0, 1, 100, 159, 160, 161, 240, 320, 401, 1000} {
+			source := MFCC(&SliceSource{Slice: make([]float64, count)}, 8000, opts)
+			expected := len(readAllCoeffs(t, source))
+			if actual := layout.FrameCount(count); actual != expected {
+				t.Errorf("options %d, %d samples: expected %d frames but got %d", i, count,
+					expected, actual)
+			}
+		}
+	}
+}
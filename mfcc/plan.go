@@ -0,0 +1,266 @@
+package mfcc
+
+import (
+	"math"
+	"sync"
+)
+
+// An FFTPlan stores precomputed tables for computing
+// discrete Fourier transforms of a fixed size.
+//
+// Sizes which are powers of 2 use radix-2 FFTs directly.
+// Other sizes use Bluestein's algorithm, which runs a
+// power of 2 FFT roughly four times as large.
+//
+// An FFTPlan may be used from many goroutines at once.
+// After a short warm-up period, its methods do not
+// allocate memory.
+type FFTPlan struct {
+	size int
+
+	// Tables for power of 2 sizes.
+	bitReverse []int
+	twiddles   []complex128
+	sines      []float64
+	cosines    []float64
+
+	// Tables for other sizes.
+	bluestein *bluesteinPlan
+
+	scratch sync.Pool
+}
+
+// planScratch stores temporary buffers for one transform.
+type planScratch struct {
+	reals     []float64
+	complexes []complex128
+	spectrum  []complex128
+}
+
+var (
+	planCacheLock sync.Mutex
+	planCache     = map[int]*FFTPlan{}
+)
+
+// NewFFTPlan creates an FFTPlan for transforms with the
+// given number of samples.
+func NewFFTPlan(size int) *FFTPlan {
+	if size < 1 {
+		panic("FFT size must be positive")
+	}
+	res := &FFTPlan{size: size}
+	if size&(size-1) != 0 {
+		res.bluestein = newBluesteinPlan(size)
+		res.scratch.New = func() interface{} {
+			return &planScratch{
+				complexes: make([]complex128, res.bluestein.inner.size),
+				spectrum:  make([]complex128, size/2+1),
+			}
+		}
+		return res
+	}
+
+	res.bitReverse = make([]int, size)
+	for i := range res.bitReverse {
+		for bit := 1; bit < size; bit <<= 1 {
+			res.bitReverse[i] <<= 1
+			if i&bit != 0 {
+				res.bitReverse[i] |= 1
+			}
+		}
+	}
+
+	res.twiddles = make([]complex128, size/2)
+	for i := range res.twiddles {
+		sin, cos := math.Sincos(-2 * math.Pi * float64(i) / float64(size))
+		res.twiddles[i] = complex(cos, sin)
+	}
+
+	res.sines = make([]float64, size/4)
+	res.cosines = make([]float64, size/4)
+	for i := range res.cosines {
+		res.sines[i], res.cosines[i] = math.Sincos(2 * math.Pi * float64(i) /
+			float64(size))
+	}
+
+	res.scratch.New = func() interface{} {
+		return &planScratch{reals: make([]float64, size*2)}
+	}
+	return res
+}
+
+// cachedFFTPlan returns a shared FFTPlan for the size.
+func cachedFFTPlan(size int) *FFTPlan {
+	planCacheLock.Lock()
+	defer planCacheLock.Unlock()
+	if plan, ok := planCache[size]; ok {
+		return plan
+	}
+	plan := NewFFTPlan(size)
+	planCache[size] = plan
+	return plan
+}
+
+// Size returns the number of samples the plan was
+// created for.
+func (p *FFTPlan) Size() int {
+	return p.size
+}
+
+// Transform computes the discrete Fourier transform
+//
+//	out[k] = sum_n in[n]*exp(-2*pi*i*n*k/N)
+//
+// where N is the size of the plan.
+//
+// Both in and out must have N entries.
+// They may be the same slice, but they must not
+// otherwise overlap.
+func (p *FFTPlan) Transform(in, out []complex128) {
+	p.checkSize(len(in), len(out))
+	if p.bluestein != nil {
+		scratch := p.scratch.Get().(*planScratch)
+		p.bluestein.Transform(in, out, scratch.complexes)
+		p.scratch.Put(scratch)
+		return
+	}
+
+	if len(in) > 0 && &in[0] == &out[0] {
+		for i, j := range p.bitReverse {
+			if i < j {
+				out[i], out[j] = out[j], out[i]
+			}
+		}
+	} else {
+		for i, j := range p.bitReverse {
+			out[j] = in[i]
+		}
+	}
+
+	n := p.size
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		twiddleStep := n / size
+		for start := 0; start < n; start += size {
+			for i := 0; i < half; i++ {
+				even := out[start+i]
+				odd := out[start+i+half] * p.twiddles[i*twiddleStep]
+				out[start+i] = even + odd
+				out[start+i+half] = even - odd
+			}
+		}
+	}
+}
+
+// Inverse computes the inverse of Transform,
+//
+//	out[n] = 1/N * sum_k in[k]*exp(2*pi*i*n*k/N).
+//
+// The same restrictions on in and out apply.
+func (p *FFTPlan) Inverse(in, out []complex128) {
+	p.checkSize(len(in), len(out))
+	for i, x := range in {
+		out[i] = complex(real(x), -imag(x))
+	}
+	p.Transform(out, out)
+	scale := 1 / float64(p.size)
+	for i, x := range out {
+		out[i] = complex(real(x)*scale, -imag(x)*scale)
+	}
+}
+
+// TransformReal computes the discrete Fourier transform
+// of a real signal.
+//
+// The input must have N entries, where N is the size of
+// the plan.
+// Since the transform of a real signal is conjugate
+// symmetric, only the first N/2+1 outputs (rounding
+// down) are computed, and out must have this length.
+func (p *FFTPlan) TransformReal(in []float64, out []complex128) {
+	if len(in) != p.size || len(out) != p.size/2+1 {
+		panic("input or output has incorrect size")
+	}
+	scratch := p.scratch.Get().(*planScratch)
+	defer p.scratch.Put(scratch)
+
+	if p.bluestein != nil {
+		p.bluestein.TransformReal(in, out, scratch.complexes)
+		return
+	}
+
+	signal := scratch.reals[:p.size]
+	copy(signal, in)
+	bins := destructiveFFT(signal, scratch.reals[p.size:], p.sines, p.cosines, 0)
+	out[0] = complex(bins.Cos[0], 0)
+	for i := 1; i < len(out); i++ {
+		var sin float64
+		if i <= len(bins.Sin) {
+			sin = bins.Sin[i-1]
+		}
+		out[i] = complex(bins.Cos[i], -sin)
+	}
+}
+
+// PowerSpectrum computes the power spectrum of a real
+// signal, which is the squared magnitude of the first
+// N/2+1 outputs of TransformReal divided by N.
+//
+// The input must have N entries, and out must have N/2+1
+// entries, where N is the size of the plan.
+func (p *FFTPlan) PowerSpectrum(in, out []float64) {
+	if len(in) != p.size || len(out) != p.size/2+1 {
+		panic("input or output has incorrect size")
+	}
+	scratch := p.scratch.Get().(*planScratch)
+	defer p.scratch.Put(scratch)
+
+	scale := 1 / float64(p.size)
+	if p.bluestein != nil {
+		spectrum := scratch.spectrum
+		p.bluestein.TransformReal(in, spectrum, scratch.complexes)
+		for i, x := range spectrum {
+			out[i] = (real(x)*real(x) + imag(x)*imag(x)) * scale
+		}
+		return
+	}
+
+	signal := scratch.reals[:p.size]
+	copy(signal, in)
+	bins := destructiveFFT(signal, scratch.reals[p.size:], p.sines, p.cosines, 0)
+	for i, c := range bins.Cos {
+		out[i] = c * c * scale
+	}
+	for i, s := range bins.Sin {
+		out[i+1] += s * s * scale
+	}
+}
+
+// bins computes the fftBins of a real signal.
+func (p *FFTPlan) bins(signal []float64) fftBins {
+	if p.bluestein != nil {
+		spectrum := make([]complex128, p.size/2+1)
+		p.TransformReal(signal, spectrum)
+		res := fftBins{
+			Cos: make([]float64, len(spectrum)),
+			Sin: make([]float64, p.size-len(spectrum)),
+		}
+		for i, x := range spectrum {
+			res.Cos[i] = real(x)
+		}
+		for i := range res.Sin {
+			res.Sin[i] = -imag(spectrum[i+1])
+		}
+		return res
+	}
+	temp := make([]float64, p.size)
+	signalCopy := make([]float64, p.size)
+	copy(signalCopy, signal)
+	return destructiveFFT(signalCopy, temp, p.sines, p.cosines, 0)
+}
+
+func (p *FFTPlan) checkSize(inSize, outSize int) {
+	if inSize != p.size || outSize != p.size {
+		panic("input or output has incorrect size")
+	}
+}
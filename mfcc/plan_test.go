@@ -0,0 +1,179 @@
+package mfcc
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+var planTestSizes = []int{1, 2, 4, 8, 16, 3, 7, 12, 400}
+
+func TestFFTPlanTransform(t *testing.T) {
+	rand.Seed(123)
+	for _, size := range planTestSizes {
+		plan := NewFFTPlan(size)
+		input := randomComplexes(size)
+		expected := naiveComplexDFT(input)
+
+		actual := make([]complex128, size)
+		plan.Transform(input, actual)
+		if !complexesClose(actual, expected) {
+			t.Errorf("size %d: expected %v but got %v", size, expected, actual)
+		}
+
+		inPlace := append([]complex128{}, input...)
+		plan.Transform(inPlace, inPlace)
+		if !complexesClose(inPlace, expected) {
+			t.Errorf("size %d: in-place transform gave %v", size, inPlace)
+		}
+
+		plan.Inverse(actual, actual)
+		if !complexesClose(actual, input) {
+			t.Errorf("size %d: inverse gave %v but expected %v", size, actual, input)
+		}
+	}
+}
+
+func TestFFTPlanReal(t *testing.T) {
+	rand.Seed(123)
+	for _, size := range planTestSizes {
+		plan := NewFFTPlan(size)
+		input := make([]float64, size)
+		complexInput := make([]complex128, size)
+		for i := range input {
+			input[i] = rand.NormFloat64()
+			complexInput[i] = complex(input[i], 0)
+		}
+		expected := naiveComplexDFT(complexInput)[:size/2+1]
+
+		actual := make([]complex128, size/2+1)
+		plan.TransformReal(input, actual)
+		if !complexesClose(actual, expected) {
+			t.Errorf("size %d: expected %v but got %v", size, expected, actual)
+		}
+
+		powers := make([]float64, size/2+1)
+		plan.PowerSpectrum(input, powers)
+		expectedPowers := fft(input).powerSpectrum()
+		if !slicesClose(powers, expectedPowers) {
+			t.Errorf("size %d: expected powers %v but got %v", size, expectedPowers, powers)
+		}
+	}
+}
+
+func TestFFTPlanConcurrency(t *testing.T) {
+	rand.Seed(123)
+	for _, size := range []int{512, 400} {
+		plan := NewFFTPlan(size)
+		inputs := make([][]complex128, 16)
+		expected := make([][]complex128, len(inputs))
+		for i := range inputs {
+			inputs[i] = randomComplexes(size)
+			expected[i] = make([]complex128, size)
+			plan.Transform(inputs[i], expected[i])
+		}
+
+		var wg sync.WaitGroup
+		for i := range inputs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				out := make([]complex128, size)
+				for j := 0; j < 20; j++ {
+					plan.Transform(inputs[i], out)
+					if !complexesClose(out, expected[i]) {
+						t.Errorf("size %d: concurrent result differs", size)
+						return
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+}
+
+func TestFFTPlanAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops buffers under the race detector")
+	}
+	for _, size := range []int{512, 400} {
+		plan := NewFFTPlan(size)
+		input := make([]float64, size)
+		powers := make([]float64, size/2+1)
+		spectrum := make([]complex128, size)
+		allocs := testing.AllocsPerRun(100, func() {
+			plan.PowerSpectrum(input, powers)
+			plan.Transform(spectrum, spectrum)
+			plan.Inverse(spectrum, spectrum)
+		})
+		if allocs != 0 {
+			t.Errorf("size %d: got %f allocations per run", size, allocs)
+		}
+	}
+}
+
+func BenchmarkFFTPlan(b *testing.B) {
+	rand.Seed(123)
+	inputVec := make([]float64, fftBenchSize)
+	for i := range inputVec {
+		inputVec[i] = rand.NormFloat64()
+	}
+	plan := NewFFTPlan(fftBenchSize)
+	powers := make([]float64, fftBenchSize/2+1)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		plan.PowerSpectrum(inputVec, powers)
+	}
+}
+
+func BenchmarkFFTPlanNonPowerOf2(b *testing.B) {
+	rand.Seed(123)
+	inputVec := make([]float64, fftBenchOddSize)
+	for i := range inputVec {
+		inputVec[i] = rand.NormFloat64()
+	}
+	plan := NewFFTPlan(fftBenchOddSize)
+	powers := make([]float64, fftBenchOddSize/2+1)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		plan.PowerSpectrum(inputVec, powers)
+	}
+}
+
+func randomComplexes(size int) []complex128 {
+	res := make([]complex128, size)
+	for i := range res {
+		res[i] = complex(rand.NormFloat64(), rand.NormFloat64())
+	}
+	return res
+}
+
+func naiveComplexDFT(signal []complex128) []complex128 {
+	n := len(signal)
+	res := make([]complex128, n)
+	for k := range res {
+		for j, x := range signal {
+			angle := -2 * math.Pi * float64((j*k)%n) / float64(n)
+			res[k] += x * cmplx.Exp(complex(0, angle))
+		}
+	}
+	return res
+}
+
+func complexesClose(c1, c2 []complex128) bool {
+	if len(c1) != len(c2) {
+		return false
+	}
+	for i, x := range c1 {
+		if cmplx.Abs(c2[i]-x) > 1e-5 {
+			return false
+		}
+	}
+	return true
+}
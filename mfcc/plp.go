@@ -0,0 +1,250 @@
+package mfcc
+
+import "math"
+
+const DefaultPLPOrder = 12
+
+// rastaPole is the pole of the RASTA filter.
+const rastaPole = 0.94
+
+// PLPOptions stores the configuration options for
+// computing PLP cepstra.
+//
+// The embedded Options control framing, windowing and
+// the FFT exactly as they do for MFCC, and KeepCount,
+// Lifter, Energy and LogFloor work the same way.
+// MelCount is the number of critical bands, which
+// defaults to one per Bark between LowFreq and HighFreq.
+// Options which only affect the Mel filter bank or the
+// DCT are ignored.
+type PLPOptions struct {
+	Options
+
+	// Order is the order of the linear prediction model.
+	// If this is 0, DefaultPLPOrder is used.
+	Order int
+
+	// RASTA can be set to band-pass filter the trajectory
+	// of every log critical band energy over time, which
+	// suppresses slowly varying channel effects.
+	RASTA bool
+}
+
+// PLP generates a CoeffSource that computes perceptual
+// linear prediction (PLP) cepstra, following Hermansky
+// (1990).
+// If options.RASTA is set, this computes RASTA-PLP
+// cepstra instead.
+//
+// Each frame's power spectrum is integrated over critical
+// bands spaced on the Bark scale, weighted by an equal
+// loudness curve, and cube-root compressed.
+// An all-pole model of the result is then converted into
+// cepstral coefficients.
+//
+// If KeepCount is 0, Order+1 coefficients are produced,
+// the first of which is the log gain of the model.
+//
+// Streaming and error behavior are the same as for MFCC.
+func PLP(source Source, sampleRate int, options *PLPOptions) FrameSource {
+	if options == nil {
+		options = &PLPOptions{}
+	}
+	layout := NewFrameLayout(sampleRate, &options.Options)
+
+	minFreq := floatOrDefault(options.LowFreq, DefaultLowFreq)
+	maxFreq := floatOrDefault(options.HighFreq, DefaultHighFreq)
+	maxFreq = math.Min(maxFreq, float64(layout.SampleRate)/2)
+
+	var bark BarkScale
+	bandCount := options.MelCount
+	if bandCount == 0 {
+		bandCount = int(math.Ceil(bark.FromHertz(maxFreq)-bark.FromHertz(minFreq))) + 1
+	}
+	bank := NewFilterBank(layout.FFTSize, layout.SampleRate, bandCount, minFreq, maxFreq,
+		&FilterBankOptions{Scale: bark, Fractional: true})
+
+	order := intOrDefault(options.Order, DefaultPLPOrder)
+	res := &plpSource{
+		powers:    newCoeffChan(source, sampleRate, &options.Options, powerStage),
+		bank:      bank,
+		loudness:  make([]float64, bandCount),
+		order:     order,
+		keepCount: intOrDefault(options.KeepCount, order+1),
+	}
+	for i, freq := range bank.Centers {
+		res.loudness[i] = equalLoudness(freq)
+	}
+	if options.RASTA {
+		res.rasta = &rastaFilter{}
+	}
+	return res
+}
+
+type plpSource struct {
+	powers    *coeffChan
+	bank      *FilterBank
+	loudness  []float64
+	rasta     *rastaFilter
+	order     int
+	keepCount int
+}
+
+func (p *plpSource) NextCoeffs() ([]float64, error) {
+	return coeffsOf(p.NextFrame())
+}
+
+func (p *plpSource) NextFrame() (*Frame, error) {
+	frame, energy, err := p.powers.nextPowers()
+	if err != nil {
+		return nil, err
+	}
+
+	bands := p.bank.Apply(frame.Coeffs)
+	if p.rasta != nil {
+		for i, x := range bands {
+			bands[i] = math.Log(math.Max(x, p.powers.logFloor))
+		}
+		p.rasta.Apply(bands)
+		for i, x := range bands {
+			bands[i] = math.Exp(x)
+		}
+	}
+	for i, x := range bands {
+		bands[i] = math.Pow(x*p.loudness[i], 0.33)
+	}
+
+	// The outermost bands are only partly covered by the
+	// spectrum, so they are replaced by their neighbors.
+	if len(bands) > 2 {
+		bands[0] = bands[1]
+		bands[len(bands)-1] = bands[len(bands)-2]
+	}
+
+	lpc, gain := levinsonDurbin(bandAutocorrelation(bands, p.order))
+	gain = math.Max(gain, p.powers.logFloor)
+	coeffs := lpcToCepstrum(lpc, gain, p.keepCount)
+	frame.Coeffs = p.powers.finishCepstrum(coeffs, energy)
+	return frame, nil
+}
+
+// equalLoudness approximates the sensitivity of human
+// hearing at a frequency, using the curve from Hermansky
+// (1990).
+func equalLoudness(freq float64) float64 {
+	w := 2 * math.Pi * freq
+	w2 := w * w
+	return (w2 + 56.8e6) * w2 * w2 / ((w2 + 6.3e6) * (w2 + 6.3e6) * (w2 + 0.38e9))
+}
+
+// bandAutocorrelation computes the first order+1 lags of
+// the autocorrelation of a signal whose power spectrum
+// has the given values, spaced evenly from 0 up to the
+// Nyquist frequency.
+func bandAutocorrelation(bands []float64, order int) []float64 {
+	res := make([]float64, order+1)
+	n := len(bands)
+	if n < 2 {
+		if n == 1 {
+			res[0] = bands[0]
+		}
+		return res
+	}
+	period := float64(2 * (n - 1))
+	for k := range res {
+		sum := bands[0]
+		if k%2 == 0 {
+			sum += bands[n-1]
+		} else {
+			sum -= bands[n-1]
+		}
+		for j := 1; j < n-1; j++ {
+			sum += 2 * bands[j] * math.Cos(math.Pi*float64(j*k)/float64(n-1))
+		}
+		res[k] = sum / period
+	}
+	return res
+}
+
+// levinsonDurbin solves for the coefficients of the
+// prediction polynomial A(z) = 1 + sum_k a[k]*z^-k which
+// best fits an autocorrelation sequence.
+// It returns a[1:] and the prediction error.
+func levinsonDurbin(autocorr []float64) (coeffs []float64, gain float64) {
+	order := len(autocorr) - 1
+	a := make([]float64, order+1)
+	a[0] = 1
+	gain = autocorr[0]
+	next := make([]float64, order+1)
+	for i := 1; i <= order && gain > 0; i++ {
+		acc := autocorr[i]
+		for j := 1; j < i; j++ {
+			acc += a[j] * autocorr[i-j]
+		}
+		k := -acc / gain
+		copy(next, a)
+		for j := 1; j < i; j++ {
+			next[j] = a[j] + k*a[i-j]
+		}
+		next[i] = k
+		a, next = next, a
+		gain *= 1 - k*k
+	}
+	return a[1:], gain
+}
+
+// lpcToCepstrum computes the first n cepstral
+// coefficients of the all-pole model gain/|A(z)|^2, where
+// A(z) is given by the results of levinsonDurbin.
+func lpcToCepstrum(lpc []float64, gain float64, n int) []float64 {
+	if n == 0 {
+		return []float64{}
+	}
+	res := make([]float64, n)
+	res[0] = math.Log(gain)
+	for i := 1; i < n; i++ {
+		var sum float64
+		if i <= len(lpc) {
+			sum = lpc[i-1]
+		}
+		for k := maxInt(1, i-len(lpc)); k < i; k++ {
+			sum += float64(k) / float64(i) * res[k] * lpc[i-k-1]
+		}
+		res[i] = -sum
+	}
+	return res
+}
+
+// A rastaFilter applies the RASTA filter
+//
+//	H(z) = 0.1*(2 + z^-1 - z^-3 - 2*z^-4) / (1 - 0.94*z^-1)
+//
+// to a sequence of vectors, one frame at a time.
+//
+// Before the first frame, the input is taken to have
+// been constant, so that steady inputs produce zeros
+// right away.
+type rastaFilter struct {
+	inputs  [4][]float64
+	outputs []float64
+}
+
+// Apply filters the next frame in place.
+func (r *rastaFilter) Apply(frame []float64) {
+	if r.outputs == nil {
+		r.outputs = make([]float64, len(frame))
+		for i := range r.inputs {
+			r.inputs[i] = append([]float64{}, frame...)
+		}
+	}
+	for i, x := range frame {
+		y := 0.1*(2*x+r.inputs[0][i]-r.inputs[2][i]-2*r.inputs[3][i]) +
+			rastaPole*r.outputs[i]
+		r.inputs[3][i] = r.inputs[2][i]
+		r.inputs[2][i] = r.inputs[1][i]
+		r.inputs[1][i] = r.inputs[0][i]
+		r.inputs[0][i] = x
+		r.outputs[i] = y
+		frame[i] = y
+	}
+}
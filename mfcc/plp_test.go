@@ -0,0 +1,153 @@
+package mfcc
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+)
+
+func TestPLPStreaming(t *testing.T) {
+	rand.Seed(123)
+	samples := make([]float64, 5000)
+	for i := range samples {
+		samples[i] = rand.NormFloat64()
+	}
+	for _, rasta := range []bool{false, true} {
+		opts := &PLPOptions{
+			Options: Options{WindowFunc: HammingWindow, Energy: AppendEnergy},
+			RASTA:   rasta,
+		}
+		expected := readAllCoeffs(t, PLP(&SliceSource{Slice: samples}, 8000, opts))
+		actual := readAllCoeffs(t, PLP(&sliceSource{vec: samples, buffSize: 7}, 8000, opts))
+		if len(actual) != len(expected) {
+			t.Fatalf("expected %d frames but got %d", len(expected), len(actual))
+		}
+		for i, frame := range expected {
+			if len(frame) != DefaultPLPOrder+2 {
+				t.Fatalf("frame %d: bad size %d", i, len(frame))
+			}
+			if !slicesClose(actual[i], frame) {
+				t.Errorf("RASTA=%v frame %d: expected %v got %v", rasta, i, frame, actual[i])
+			}
+		}
+	}
+}
+
+func TestPLPSilence(t *testing.T) {
+	for _, rasta := range []bool{false, true} {
+		source := PLP(&SliceSource{Slice: make([]float64, 2000)}, 8000,
+			&PLPOptions{RASTA: rasta})
+		for _, frame := range readAllCoeffs(t, source) {
+			for _, x := range frame {
+				if math.IsNaN(x) || math.IsInf(x, 0) {
+					t.Fatalf("RASTA=%v: bad coefficients %v", rasta, frame)
+				}
+			}
+		}
+	}
+}
+
+func TestPLPGain(t *testing.T) {
+	// Scaling the signal should only change the log gain.
+	rand.Seed(123)
+	samples := make([]float64, 3000)
+	scaled := make([]float64, len(samples))
+	for i := range samples {
+		samples[i] = rand.NormFloat64()
+		scaled[i] = samples[i] * 10
+	}
+	opts := &PLPOptions{Options: Options{WindowFunc: HannWindow}}
+	plain := readAllCoeffs(t, PLP(&SliceSource{Slice: samples}, 8000, opts))
+	louder := readAllCoeffs(t, PLP(&SliceSource{Slice: scaled}, 8000, opts))
+	expectedGain := 0.33 * 2 * math.Log(10)
+	for i, frame := range plain {
+		if math.Abs(louder[i][0]-frame[0]-expectedGain) > 1e-5 {
+			t.Errorf("frame %d: expected gain change %f but got %f", i, expectedGain,
+				louder[i][0]-frame[0])
+		}
+		if !slicesClose(louder[i][1:], frame[1:]) {
+			t.Errorf("frame %d: cepstrum changed with volume", i)
+		}
+	}
+}
+
+func TestLevinsonDurbin(t *testing.T) {
+	// The autocorrelation of the AR(2) process
+	// x[t] = 0.5*x[t-1] - 0.3*x[t-2] + e[t].
+	a1, a2 := 0.5, -0.3
+	autocorr := make([]float64, 5)
+	autocorr[0] = 1
+	autocorr[1] = a1 / (1 - a2)
+	for i := 2; i < len(autocorr); i++ {
+		autocorr[i] = a1*autocorr[i-1] + a2*autocorr[i-2]
+	}
+	coeffs, gain := levinsonDurbin(autocorr)
+	expected := []float64{-a1, -a2, 0, 0}
+	if !slicesClose(coeffs, expected) {
+		t.Errorf("expected %v got %v", expected, coeffs)
+	}
+	expectedGain := 1 - a1*autocorr[1] - a2*autocorr[2]
+	if math.Abs(gain-expectedGain) > 1e-8 {
+		t.Errorf("expected gain %f got %f", expectedGain, gain)
+	}
+}
+
+func TestLPCToCepstrum(t *testing.T) {
+	lpc := []float64{-0.9, 0.4, -0.1}
+	gain := 2.5
+	actual := lpcToCepstrum(lpc, gain, 8)
+
+	// Compute the cepstrum of the model's log spectrum
+	// numerically.
+	const points = 1024
+	expected := make([]float64, len(actual))
+	for i := 0; i < points; i++ {
+		w := 2 * math.Pi * float64(i) / points
+		a := complex(1, 0)
+		for k, c := range lpc {
+			a += complex(c, 0) * cmplx.Exp(complex(0, -w*float64(k+1)))
+		}
+		logSpec := math.Log(gain / (real(a)*real(a) + imag(a)*imag(a)))
+		for n := range expected {
+			expected[n] += logSpec * math.Cos(w*float64(n)) / points
+		}
+	}
+	if !slicesClose(actual, expected) {
+		t.Errorf("expected %v got %v", expected, actual)
+	}
+}
+
+func TestRASTAFilter(t *testing.T) {
+	var filter rastaFilter
+	frame := []float64{3, -1}
+	for i := 0; i < 5; i++ {
+		f := append([]float64{}, frame...)
+		filter.Apply(f)
+		if !slicesClose(f, []float64{0, 0}) {
+			t.Errorf("step %d: constant input gave %v", i, f)
+		}
+	}
+
+	// A unit step after the constant start gives the
+	// cumulative sum of the impulse response.
+	impulse := []float64{0.2, 0.1, 0, -0.1, -0.2}
+	var stepFilter rastaFilter
+	stepFilter.Apply([]float64{0})
+	outputs := make([]float64, 8)
+	for i := range outputs {
+		f := []float64{1}
+		stepFilter.Apply(f)
+		outputs[i] = f[0]
+	}
+	var y, cum float64
+	for i, actual := range outputs {
+		if i < len(impulse) {
+			cum += impulse[i]
+		}
+		y = cum + rastaPole*y
+		if math.Abs(actual-y) > 1e-8 {
+			t.Errorf("step %d: expected %f got %f", i, y, actual)
+		}
+	}
+}
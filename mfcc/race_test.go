@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package mfcc
+
+// raceEnabled is set when testing with -race, where
+// sync.Pool randomly drops items.
+const raceEnabled = true
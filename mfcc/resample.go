@@ -0,0 +1,209 @@
+package mfcc
+
+import "math"
+
+// ResampleQuality specifies the algorithm used to change
+// the sample rate of a Source.
+type ResampleQuality int
+
+const (
+	// LinearQuality uses linear interpolation between
+	// neighboring samples.
+	// It is fast, but it aliases badly when downsampling.
+	LinearQuality ResampleQuality = iota
+
+	// LowQuality, MediumQuality, and HighQuality use a
+	// Kaiser-windowed sinc filter to band-limit the
+	// signal while resampling.
+	// Higher qualities use longer filters, which reject
+	// more aliasing at the expense of speed.
+	LowQuality
+	MediumQuality
+	HighQuality
+)
+
+// sincParams describes the low-pass filter used for one
+// of the windowed-sinc qualities.
+type sincParams struct {
+	// zeroCrossings is the number of zero crossings of
+	// the sinc function on each side of the filter.
+	zeroCrossings int
+
+	// beta is the Kaiser window parameter.
+	beta float64
+
+	// rolloff is the cutoff frequency as a fraction of
+	// the lower of the two Nyquist frequencies.
+	rolloff float64
+}
+
+var sincQualities = map[ResampleQuality]sincParams{
+	LowQuality:    {zeroCrossings: 8, beta: 6, rolloff: 0.85},
+	MediumQuality: {zeroCrossings: 16, beta: 8.5, rolloff: 0.9},
+	HighQuality:   {zeroCrossings: 32, beta: 10, rolloff: 0.95},
+}
+
+// sincTableResolution is the number of filter table
+// entries per zero crossing of the sinc function.
+const sincTableResolution = 512
+
+// Resample creates a Source which changes the sample
+// rate of s.
+//
+// The ratio argument is the ratio of the new sample rate
+// to the old one.
+// For example, a ratio of 16000.0/44100 would turn a
+// 44.1KHz Source into a 16KHz one.
+//
+// The first output sample always lines up with the first
+// input sample.
+// With the sinc qualities, the output contains
+// ceil(n*ratio) samples for an input of n samples.
+// LinearQuality only produces output samples which lie
+// between two input samples, so it may produce fewer;
+// for example, a single input sample gives no output.
+func Resample(s Source, ratio float64, quality ResampleQuality) Source {
+	if quality == LinearQuality {
+		return &rateChanger{S: s, Ratio: ratio}
+	}
+	params, ok := sincQualities[quality]
+	if !ok {
+		panic("unknown resample quality")
+	}
+	if ratio == 1 {
+		return s
+	}
+	return newSincResampler(s, ratio, params)
+}
+
+// A sincResampler resamples a Source by convolving it
+// with a windowed-sinc low-pass filter evaluated at each
+// output sample's position in the input.
+type sincResampler struct {
+	S     Source
+	Ratio float64
+
+	// table stores the filter kernel at non-negative
+	// offsets, spaced 1/tableScale input samples apart.
+	table      []float64
+	tableScale float64
+	halfWidth  float64
+
+	buf       []float64
+	bufStart  int
+	outCount  int
+	srcError  error
+	doneError error
+}
+
+func newSincResampler(s Source, ratio float64, p sincParams) *sincResampler {
+	// The cutoff is measured in cycles per input sample.
+	cutoff := 0.5 * p.rolloff * math.Min(1, ratio)
+	halfWidth := float64(p.zeroCrossings) / (2 * cutoff)
+	tableScale := 2 * cutoff * sincTableResolution
+
+	table := make([]float64, p.zeroCrossings*sincTableResolution+2)
+	besselNorm := besselI0(p.beta)
+	for i := range table {
+		x := float64(i) / tableScale
+		if x >= halfWidth {
+			break
+		}
+		sincArg := math.Pi * 2 * cutoff * x
+		sinc := 1.0
+		if sincArg != 0 {
+			sinc = math.Sin(sincArg) / sincArg
+		}
+		windowArg := x / halfWidth
+		window := besselI0(p.beta*math.Sqrt(1-windowArg*windowArg)) / besselNorm
+		table[i] = 2 * cutoff * sinc * window
+	}
+
+	return &sincResampler{
+		S:          s,
+		Ratio:      ratio,
+		table:      table,
+		tableScale: tableScale,
+		halfWidth:  halfWidth,
+	}
+}
+
+func (r *sincResampler) ReadSamples(s []float64) (n int, err error) {
+	if r.doneError != nil {
+		return 0, r.doneError
+	}
+	for i := range s {
+		center := float64(r.outCount) / r.Ratio
+		lastNeeded := int(math.Floor(center + r.halfWidth))
+		for r.srcError == nil && r.bufStart+len(r.buf) <= lastNeeded {
+			r.fill()
+		}
+		inCount := r.bufStart + len(r.buf)
+		if r.srcError != nil && center >= float64(inCount) {
+			r.doneError = r.srcError
+			return n, r.doneError
+		}
+		s[i] = r.interpolate(center)
+		r.outCount++
+		n++
+	}
+	r.discardBefore(int(math.Ceil(float64(r.outCount)/r.Ratio - r.halfWidth)))
+	return
+}
+
+func (r *sincResampler) interpolate(center float64) float64 {
+	start := int(math.Ceil(center - r.halfWidth))
+	if start < r.bufStart {
+		start = r.bufStart
+	}
+	end := int(math.Floor(center + r.halfWidth))
+	if max := r.bufStart + len(r.buf) - 1; end > max {
+		end = max
+	}
+	var sum float64
+	for k := start; k <= end; k++ {
+		tablePos := math.Abs(center-float64(k)) * r.tableScale
+		idx := int(tablePos)
+		frac := tablePos - float64(idx)
+		weight := r.table[idx]*(1-frac) + r.table[idx+1]*frac
+		sum += weight * r.buf[k-r.bufStart]
+	}
+	return sum
+}
+
+func (r *sincResampler) fill() {
+	var chunk [512]float64
+	n, err := r.S.ReadSamples(chunk[:])
+	r.buf = append(r.buf, chunk[:n]...)
+	if err != nil {
+		r.srcError = err
+	}
+}
+
+// discardBefore removes buffered samples with indices
+// lower than idx.
+func (r *sincResampler) discardBefore(idx int) {
+	drop := idx - r.bufStart
+	if drop <= 0 {
+		return
+	}
+	if drop > len(r.buf) {
+		drop = len(r.buf)
+	}
+	copy(r.buf, r.buf[drop:])
+	r.buf = r.buf[:len(r.buf)-drop]
+	r.bufStart += drop
+}
+
+// besselI0 computes the zeroth-order modified Bessel
+// function of the first kind using its power series.
+func besselI0(x float64) float64 {
+	sum := 1.0
+	term := 1.0
+	halfX := x / 2
+	for k := 1; term > sum*1e-17; k++ {
+		term *= (halfX / float64(k)) * (halfX / float64(k))
+		sum += term
+	}
+	return sum
+}
@@ -0,0 +1,134 @@
+package mfcc
+
+import (
+	"io"
+	"math"
+	"testing"
+)
+
+const (
+	resampleTestInRate  = 44100
+	resampleTestOutRate = 16000
+)
+
+func TestResampleLength(t *testing.T) {
+	for _, quality := range []ResampleQuality{LowQuality, MediumQuality, HighQuality} {
+		for _, ratio := range []float64{0.5, 16000.0 / 44100, 2.5} {
+			input := make([]float64, 1001)
+			source := Resample(&sliceSource{vec: input, buffSize: 7}, ratio, quality)
+			output := readAllSamples(t, source)
+			expected := int(math.Ceil(float64(len(input)) * ratio))
+			if len(output) != expected {
+				t.Errorf("quality %d ratio %f: expected %d samples but got %d",
+					quality, ratio, expected, len(output))
+			}
+		}
+	}
+}
+
+func TestResampleStreaming(t *testing.T) {
+	input := chirp(0, 20000, resampleTestInRate, 3000)
+	ratio := float64(resampleTestOutRate) / resampleTestInRate
+	expected := readAllSamples(t, Resample(&SliceSource{Slice: input}, ratio, HighQuality))
+	actual := readAllSamples(t, Resample(&sliceSource{vec: input, buffSize: 3}, ratio,
+		HighQuality))
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d samples but got %d", len(expected), len(actual))
+	}
+	if !slicesClose(actual, expected) {
+		t.Error("results depend on read sizes")
+	}
+}
+
+func TestResamplePassband(t *testing.T) {
+	for _, quality := range []ResampleQuality{LowQuality, MediumQuality, HighQuality} {
+		input := chirp(100, 4000, resampleTestInRate, resampleTestInRate)
+		output := resampleTestSignal(t, input, quality)
+		gain := rms(output) / rms(input)
+		if math.Abs(gain-1) > 1e-3 {
+			t.Errorf("quality %d: passband gain should be 1 but got %f", quality, gain)
+		}
+	}
+}
+
+func TestResampleAliasing(t *testing.T) {
+	// All of these frequencies are above the new Nyquist
+	// rate, so they should be filtered out rather than
+	// folded back into the output.
+	input := chirp(9000, 22000, resampleTestInRate, resampleTestInRate)
+
+	minRejections := map[ResampleQuality]float64{
+		LowQuality:    60,
+		MediumQuality: 80,
+		HighQuality:   95,
+	}
+	for quality, minRejection := range minRejections {
+		output := resampleTestSignal(t, input, quality)
+		rejection := -20 * math.Log10(rms(output)/rms(input))
+		if rejection < minRejection {
+			t.Errorf("quality %d: expected %.0fdB rejection but got %.1fdB", quality,
+				minRejection, rejection)
+		}
+	}
+
+	output := resampleTestSignal(t, input, LinearQuality)
+	if rejection := -20 * math.Log10(rms(output)/rms(input)); rejection > 20 {
+		t.Errorf("linear rejection is suspiciously good: %.1fdB", rejection)
+	}
+}
+
+func BenchmarkResample(b *testing.B) {
+	input := chirp(0, 20000, resampleTestInRate, resampleTestInRate)
+	ratio := float64(resampleTestOutRate) / resampleTestInRate
+	output := make([]float64, resampleTestOutRate+1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		source := Resample(&SliceSource{Slice: input}, ratio, MediumQuality)
+		source.ReadSamples(output)
+	}
+}
+
+// resampleTestSignal converts a signal to the output
+// rate and trims the edges, where the filters see zero
+// padding.
+func resampleTestSignal(t *testing.T, input []float64, q ResampleQuality) []float64 {
+	ratio := float64(resampleTestOutRate) / resampleTestInRate
+	output := readAllSamples(t, Resample(&SliceSource{Slice: input}, ratio, q))
+	edge := len(output) / 10
+	return output[edge : len(output)-edge]
+}
+
+// chirp generates a linear frequency sweep with unit
+// amplitude.
+func chirp(startFreq, endFreq float64, rate, count int) []float64 {
+	res := make([]float64, count)
+	duration := float64(count) / float64(rate)
+	for i := range res {
+		t := float64(i) / float64(rate)
+		phase := 2 * math.Pi * (startFreq*t + (endFreq-startFreq)*t*t/(2*duration))
+		res[i] = math.Sin(phase)
+	}
+	return res
+}
+
+func rms(samples []float64) float64 {
+	var sum float64
+	for _, x := range samples {
+		sum += x * x
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func readAllSamples(t *testing.T, s Source) []float64 {
+	var res []float64
+	var buf [100]float64
+	for {
+		n, err := s.ReadSamples(buf[:])
+		res = append(res, buf[:n]...)
+		if err == io.EOF {
+			return res
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
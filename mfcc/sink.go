@@ -0,0 +1,49 @@
+package mfcc
+
+import "io"
+
+// A Sink is a place to which audio sample data can be
+// written.
+// This interface is very similar to io.Writer, except
+// that it deals with samples instead of bytes.
+//
+// If n < len(s), err must be non-nil.
+type Sink interface {
+	WriteSamples(s []float64) (n int, err error)
+}
+
+// A SliceSink is a Sink which appends samples to a
+// slice.
+type SliceSink struct {
+	Slice []float64
+}
+
+func (s *SliceSink) WriteSamples(samples []float64) (n int, err error) {
+	s.Slice = append(s.Slice, samples...)
+	return len(samples), nil
+}
+
+// Copy reads samples from a Source and writes them to a
+// Sink until the Source returns io.EOF or either one
+// returns another error.
+//
+// It returns the number of samples written, and the first
+// error other than io.EOF.
+func Copy(dst Sink, src Source) (written int64, err error) {
+	var buf [1024]float64
+	for {
+		n, readErr := src.ReadSamples(buf[:])
+		if n > 0 {
+			m, writeErr := dst.WriteSamples(buf[:n])
+			written += int64(m)
+			if writeErr != nil {
+				return written, writeErr
+			}
+		}
+		if readErr == io.EOF {
+			return written, nil
+		} else if readErr != nil {
+			return written, readErr
+		}
+	}
+}
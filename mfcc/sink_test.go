@@ -0,0 +1,35 @@
+package mfcc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	vec := make([]float64, 2500)
+	for i := range vec {
+		vec[i] = float64(i)
+	}
+	var sink SliceSink
+	n, err := Copy(&sink, &sliceSource{vec: vec, buffSize: 700})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(vec)) {
+		t.Errorf("expected %d samples but got %d", len(vec), n)
+	}
+	if len(sink.Slice) != len(vec) || !slicesClose(sink.Slice, vec) {
+		t.Error("incorrect samples written")
+	}
+
+	testErr := errors.New("test error")
+	source := Concat(&sliceSource{vec: vec[:10], buffSize: 10}, &errorSource{testErr})
+	sink = SliceSink{}
+	n, err = Copy(&sink, source)
+	if err != testErr {
+		t.Errorf("expected %v but got %v", testErr, err)
+	}
+	if n != 10 || len(sink.Slice) != 10 {
+		t.Errorf("expected 10 samples but got %d", n)
+	}
+}
@@ -199,3 +199,27 @@ func (r *rateChanger) readNext() (noSample bool, err error) {
 	r.nextSample = samples[0]
 	return
 }
+
+// A preEmphasizer applies a first-order high-pass filter
+// y[n] = x[n] - Coeff*x[n-1] to a Source.
+//
+// The filter state is carried across calls to
+// ReadSamples, so the output does not depend on how
+// reads from the wrapped Source are split up.
+// The first sample is treated as if it were preceded by
+// a zero sample.
+type preEmphasizer struct {
+	S     Source
+	Coeff float64
+
+	last float64
+}
+
+func (p *preEmphasizer) ReadSamples(s []float64) (n int, err error) {
+	n, err = p.S.ReadSamples(s)
+	for i, x := range s[:n] {
+		s[i] = x - p.Coeff*p.last
+		p.last = x
+	}
+	return
+}
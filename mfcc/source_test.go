@@ -56,7 +56,7 @@ func TestFramer(t *testing.T) {
 	}
 }
 
-func TestrateChanger(t *testing.T) {
+func TestRateChanger(t *testing.T) {
 	var data [20]float64
 
 	source := sliceSource{vec: []float64{1, -1, 0.5, 0.3, 0.2, 1, 0.5}, buffSize: 2}
@@ -96,3 +96,27 @@ func slicesClose(s1, s2 []float64) bool {
 	}
 	return true
 }
+
+func TestPreEmphasizer(t *testing.T) {
+	var data [10]float64
+
+	source := sliceSource{vec: []float64{1, -1, 0.5, 0.3, 0.2, 1, 0.5}, buffSize: 2}
+	emph := preEmphasizer{S: &source, Coeff: 0.5}
+
+	var n int
+	var err error
+	for err == nil {
+		var count int
+		count, err = emph.ReadSamples(data[n:])
+		n += count
+	}
+	if err != io.EOF {
+		t.Errorf("expected EOF error, got %v", err)
+	}
+	expected := []float64{1, -1.5, 1, 0.05, 0.05, 0.9, 0}
+	if n != len(expected) {
+		t.Errorf("expected %d outputs but got %d", len(expected), n)
+	} else if !slicesClose(data[:len(expected)], expected) {
+		t.Errorf("expected slice %v but got %v", expected, data[:len(expected)])
+	}
+}
@@ -0,0 +1,51 @@
+package mfcc
+
+// Tee generates n Sources which each produce all of the
+// samples from s, so that several features can be
+// computed from a single stream of audio.
+//
+// Samples are buffered until every Source has read them,
+// so the Sources should be read at similar rates, as
+// JoinCoeffs does.
+func Tee(s Source, n int) []Source {
+	t := &tee{Wrapped: s, buffers: make([][]float64, n)}
+	res := make([]Source, n)
+	for i := range res {
+		res[i] = &teeSource{tee: t, index: i}
+	}
+	return res
+}
+
+type tee struct {
+	Wrapped Source
+
+	buffers   [][]float64
+	doneError error
+}
+
+type teeSource struct {
+	tee   *tee
+	index int
+}
+
+func (t *teeSource) ReadSamples(s []float64) (n int, err error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	shared := t.tee
+	for len(shared.buffers[t.index]) == 0 {
+		if shared.doneError != nil {
+			return 0, shared.doneError
+		}
+		read, err := shared.Wrapped.ReadSamples(s)
+		for i, buf := range shared.buffers {
+			shared.buffers[i] = append(buf, s[:read]...)
+		}
+		if err != nil {
+			shared.doneError = err
+		}
+	}
+	n = copy(s, shared.buffers[t.index])
+	shared.buffers[t.index] = shared.buffers[t.index][n:]
+	return n, nil
+}
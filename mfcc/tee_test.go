@@ -0,0 +1,43 @@
+package mfcc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTee(t *testing.T) {
+	signal := []float64{1, 2, 3, 4, 5, 6, 7}
+	sources := Tee(&sliceSource{vec: signal, buffSize: 3}, 3)
+
+	// Read the sources unevenly to exercise buffering.
+	first := make([]float64, 5)
+	n, err := sources[0].ReadSamples(first)
+	if err != nil || !slicesClose(first[:n], signal[:n]) {
+		t.Errorf("unexpected first read %v (%v)", first[:n], err)
+	}
+	for i, source := range sources {
+		actual := readAllSamples(t, source)
+		expected := signal
+		if i == 0 {
+			expected = signal[n:]
+		}
+		if len(actual) != len(expected) || !slicesClose(actual, expected) {
+			t.Errorf("source %d: expected %v but got %v", i, expected, actual)
+		}
+	}
+
+	testErr := errors.New("test error")
+	for i, source := range Tee(&errorSource{err: testErr}, 2) {
+		if _, err := source.ReadSamples(make([]float64, 3)); err != testErr {
+			t.Errorf("source %d: expected %v but got %v", i, testErr, err)
+		}
+	}
+}
+
+type errorSource struct {
+	err error
+}
+
+func (e *errorSource) ReadSamples(s []float64) (int, error) {
+	return 0, e.err
+}
@@ -0,0 +1,381 @@
+package mfcc
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	DefaultVADFrame     = 10 * time.Millisecond
+	DefaultVADHangover  = 300 * time.Millisecond
+	DefaultVADMinSpeech = 50 * time.Millisecond
+	DefaultVADThreshold = 10
+	DefaultVADMinLevel  = -50
+
+	DefaultVADInitialWindow = 500 * time.Millisecond
+)
+
+// Rates at which the VAD noise floor rises toward the
+// energy of non-speech and speech frames, respectively.
+// The noise floor falls to quieter frames immediately.
+const (
+	vadNoiseAdapt  = 0.05
+	vadSpeechAdapt = 0.005
+)
+
+// VADOptions stores configuration options for voice
+// activity detection.
+type VADOptions struct {
+	// Frame is the duration of the frames whose energies
+	// are compared to the noise floor.
+	// If this is 0, DefaultVADFrame is used.
+	Frame time.Duration
+
+	// Hangover is how long a segment continues after the
+	// last speech frame, so that short pauses and quiet
+	// word endings are not cut off.
+	// If this is 0, DefaultVADHangover is used.
+	Hangover time.Duration
+
+	// MinSpeech is how long the energy must stay above
+	// the threshold for a segment to start.
+	// If this is 0, DefaultVADMinSpeech is used.
+	MinSpeech time.Duration
+
+	// Threshold is how far above the noise floor, in
+	// decibels, a frame's energy must be for it to count
+	// as speech.
+	// If this is 0, DefaultVADThreshold is used.
+	Threshold float64
+
+	// MinLevel is the lowest energy, in decibels relative
+	// to a full-scale signal, which can count as speech.
+	// If this is 0, DefaultVADMinLevel is used.
+	MinLevel float64
+
+	// InitialWindow is the length of audio at the start
+	// whose quietest frame sets the initial noise floor.
+	// Frames are not classified until it has been read.
+	// If this is 0, DefaultVADInitialWindow is used.
+	InitialWindow time.Duration
+}
+
+// A Segment is a range of samples from a Source.
+type Segment struct {
+	// Start is the index of the first sample.
+	Start int
+
+	// End is the index after the last sample.
+	End int
+}
+
+// A SegmentSource produces a sequence of Segments.
+type SegmentSource interface {
+	// NextSegment returns the next Segment, or an error
+	// if the underlying Source ended with one.
+	NextSegment() (Segment, error)
+}
+
+// SpeechSegments generates a SegmentSource which finds
+// the segments of speech in a Source using an adaptive
+// energy threshold.
+//
+// The noise floor is tracked as the source is read,
+// starting from the quietest frame in the initial window,
+// so a recording may begin with speech as long as there
+// is a pause somewhere in that window.
+// Segments are returned in order once they end, and they
+// never overlap.
+func SpeechSegments(s Source, sampleRate int, options *VADOptions) SegmentSource {
+	return &segmentSource{
+		Source:   s,
+		detector: newVADDetector(sampleRate, options),
+	}
+}
+
+type segmentSource struct {
+	Source   Source
+	detector *vadDetector
+
+	pending   []Segment
+	doneError error
+}
+
+func (s *segmentSource) NextSegment() (Segment, error) {
+	for len(s.pending) == 0 && s.doneError == nil {
+		frame, err := s.detector.ReadFrame(s.Source)
+		if len(frame) > 0 {
+			s.pending = append(s.pending, s.detector.Process(frame)...)
+		}
+		if err != nil {
+			s.doneError = err
+			s.pending = append(s.pending, s.detector.Finish()...)
+		}
+	}
+	if len(s.pending) == 0 {
+		return Segment{}, s.doneError
+	}
+	res := s.pending[0]
+	s.pending = s.pending[1:]
+	return res, nil
+}
+
+// TrimSilence generates a Source which skips the silence
+// at the start and end of a Source, as found by
+// SpeechSegments.
+// Pauses between segments of speech are kept.
+//
+// Samples after the latest speech are held back until
+// more speech arrives or the source ends, so long pauses
+// use memory proportional to their length.
+func TrimSilence(s Source, sampleRate int, options *VADOptions) Source {
+	return &silenceTrimmer{
+		Source:   s,
+		detector: newVADDetector(sampleRate, options),
+	}
+}
+
+type silenceTrimmer struct {
+	Source   Source
+	detector *vadDetector
+
+	// held stores the samples starting at heldStart which
+	// have been read but not yet returned.
+	held      []float64
+	heldStart int
+
+	// ready is the number of held samples which may be
+	// returned.
+	ready int
+
+	started   bool
+	doneError error
+}
+
+func (s *silenceTrimmer) ReadSamples(out []float64) (n int, err error) {
+	for s.ready == 0 && s.doneError == nil {
+		frame, err := s.detector.ReadFrame(s.Source)
+		if len(frame) > 0 {
+			s.held = append(s.held, frame...)
+			s.detector.Process(frame)
+			if !s.started {
+				// Nothing before a potential onset can
+				// ever be part of the output.
+				s.drop(s.detector.heldFrom - s.heldStart)
+				s.started = s.detector.inSegment
+			}
+			if s.started {
+				s.ready = s.detector.lastSpeechEnd - s.heldStart
+			}
+		}
+		if err != nil {
+			s.doneError = err
+			s.detector.flushInitial()
+			if !s.started {
+				s.drop(s.detector.heldFrom - s.heldStart)
+				s.started = s.detector.inSegment
+			}
+			if s.started {
+				end := minInt(s.detector.lastSpeechEnd+s.detector.hangover,
+					s.detector.offset)
+				s.ready = end - s.heldStart
+			}
+		}
+	}
+
+	n = copy(out, s.held[:s.ready])
+	s.drop(n)
+	s.ready -= n
+	if s.ready == 0 && s.doneError != nil {
+		s.held = nil
+		return n, s.doneError
+	}
+	return n, nil
+}
+
+func (s *silenceTrimmer) drop(count int) {
+	s.held = append(s.held[:0], s.held[count:]...)
+	s.heldStart += count
+}
+
+// vadDetector classifies frames of audio as speech or
+// non-speech and groups speech frames into segments.
+type vadDetector struct {
+	frameSize int
+	hangover  int
+	minFrames int
+	threshold float64
+	minLevel  float64
+
+	noiseFloor float64
+	started    bool
+
+	// initial stores the levels and sizes of the frames
+	// in the initial window until it has been read.
+	initialFrames int
+	initial       []vadLevel
+
+	// offset is the index of the next frame's first
+	// sample.
+	offset int
+
+	// runStart is the start of the current run of speech
+	// frames, and runLength is the number of frames in it.
+	runStart  int
+	runLength int
+
+	// heldFrom is the earliest sample which might start a
+	// segment, given what has been read so far.
+	heldFrom int
+
+	inSegment     bool
+	segStart      int
+	lastSpeechEnd int
+}
+
+func newVADDetector(sampleRate int, options *VADOptions) *vadDetector {
+	if options == nil {
+		options = &VADOptions{}
+	}
+	samples := func(d, def time.Duration) int {
+		if d == 0 {
+			d = def
+		}
+		return int(d.Seconds()*float64(sampleRate) + 0.5)
+	}
+	res := &vadDetector{
+		frameSize: maxInt(1, samples(options.Frame, DefaultVADFrame)),
+		hangover:  samples(options.Hangover, DefaultVADHangover),
+		threshold: floatOrDefault(options.Threshold, DefaultVADThreshold),
+		minLevel:  floatOrDefault(options.MinLevel, DefaultVADMinLevel),
+	}
+	minSpeech := samples(options.MinSpeech, DefaultVADMinSpeech)
+	res.minFrames = maxInt(1, (minSpeech+res.frameSize-1)/res.frameSize)
+	initial := samples(options.InitialWindow, DefaultVADInitialWindow)
+	res.initialFrames = maxInt(1, (initial+res.frameSize-1)/res.frameSize)
+	return res
+}
+
+// vadLevel is the energy and size of one frame.
+type vadLevel struct {
+	level float64
+	size  int
+}
+
+// ReadFrame reads the next frame from a Source.
+// If the Source ends with an error, the frame may be
+// partial or empty, and the error is returned with it.
+func (v *vadDetector) ReadFrame(s Source) ([]float64, error) {
+	frame := make([]float64, v.frameSize)
+	var have int
+	var err error
+	for have < len(frame) && err == nil {
+		var n int
+		n, err = s.ReadSamples(frame[have:])
+		have += n
+	}
+	return frame[:have], err
+}
+
+// Process classifies the next frame, or stores it if the
+// initial window has not been read yet.
+// It returns any segments which this ends.
+func (v *vadDetector) Process(frame []float64) []Segment {
+	var energy float64
+	for _, x := range frame {
+		energy += x * x
+	}
+	level := 10 * math.Log10(energy/float64(len(frame))+1e-10)
+
+	if !v.started {
+		v.initial = append(v.initial, vadLevel{level: level, size: len(frame)})
+		if len(v.initial) < v.initialFrames {
+			return nil
+		}
+		return v.flushInitial()
+	}
+	if seg, ok := v.classify(level, len(frame)); ok {
+		return []Segment{seg}
+	}
+	return nil
+}
+
+// flushInitial sets the noise floor from the frames in
+// the initial window and classifies them.
+// It does nothing if this has already been done.
+func (v *vadDetector) flushInitial() []Segment {
+	if v.started {
+		return nil
+	}
+	v.started = true
+	if len(v.initial) == 0 {
+		return nil
+	}
+	v.noiseFloor = v.initial[0].level
+	for _, frame := range v.initial {
+		v.noiseFloor = math.Min(v.noiseFloor, frame.level)
+	}
+	var res []Segment
+	for _, frame := range v.initial {
+		if seg, ok := v.classify(frame.level, frame.size); ok {
+			res = append(res, seg)
+		}
+	}
+	v.initial = nil
+	return res
+}
+
+func (v *vadDetector) classify(level float64, size int) (seg Segment, ended bool) {
+	speech := level > v.noiseFloor+v.threshold && level > v.minLevel
+	if level < v.noiseFloor {
+		v.noiseFloor = level
+	} else if speech {
+		v.noiseFloor += vadSpeechAdapt * (level - v.noiseFloor)
+	} else {
+		v.noiseFloor += vadNoiseAdapt * (level - v.noiseFloor)
+	}
+
+	start := v.offset
+	v.offset += size
+
+	if !speech {
+		v.runLength = 0
+		if v.inSegment && v.offset >= v.lastSpeechEnd+v.hangover {
+			v.inSegment = false
+			seg, ended = Segment{Start: v.segStart, End: v.lastSpeechEnd + v.hangover}, true
+		}
+		if !v.inSegment {
+			v.heldFrom = v.offset
+		}
+		return
+	}
+
+	if v.runLength == 0 {
+		v.runStart = start
+	}
+	v.runLength++
+	if v.inSegment {
+		v.lastSpeechEnd = v.offset
+	} else if v.runLength >= v.minFrames {
+		v.inSegment = true
+		v.segStart = v.runStart
+		v.lastSpeechEnd = v.offset
+	} else {
+		v.heldFrom = v.runStart
+	}
+	return
+}
+
+// Finish returns the remaining segments at the end of
+// the audio, ending the current one if there is one.
+func (v *vadDetector) Finish() []Segment {
+	res := v.flushInitial()
+	if !v.inSegment {
+		return res
+	}
+	v.inSegment = false
+	return append(res, Segment{
+		Start: v.segStart,
+		End:   minInt(v.lastSpeechEnd+v.hangover, v.offset),
+	})
+}
@@ -0,0 +1,143 @@
+package mfcc
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSpeechSegments(t *testing.T) {
+	signal := vadTestSignal()
+	source := SpeechSegments(&sliceSource{vec: signal, buffSize: 37}, 8000, nil)
+	var segments []Segment
+	for {
+		seg, err := source.NextSegment()
+		if err != nil {
+			break
+		}
+		segments = append(segments, seg)
+	}
+
+	// The first two bursts are separated by less than the
+	// hangover, so they form one segment.
+	hangover := 2400
+	expected := []Segment{{4000, 12000 + hangover}, {20000, 22400 + hangover}}
+	if len(segments) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, segments)
+	}
+	for i, seg := range segments {
+		if abs := math.Abs(float64(seg.Start - expected[i].Start)); abs > 80 {
+			t.Errorf("segment %d: expected start %d got %d", i, expected[i].Start, seg.Start)
+		}
+		if abs := math.Abs(float64(seg.End - expected[i].End)); abs > 80 {
+			t.Errorf("segment %d: expected end %d got %d", i, expected[i].End, seg.End)
+		}
+	}
+}
+
+func TestSpeechSegmentsEnd(t *testing.T) {
+	// A segment which is still open at the end of the
+	// audio should end with the audio.
+	signal := vadTestSignal()[:21000]
+	source := SpeechSegments(&SliceSource{Slice: signal}, 8000, nil)
+	var last Segment
+	for {
+		seg, err := source.NextSegment()
+		if err != nil {
+			break
+		}
+		last = seg
+	}
+	if last.End != len(signal) {
+		t.Errorf("expected end %d got %d", len(signal), last.End)
+	}
+}
+
+func TestSpeechSegmentsStart(t *testing.T) {
+	// The recording starts with speech, but the initial
+	// window contains a pause which sets the noise floor.
+	signal := vadTestSignal()[10000:]
+	source := SpeechSegments(&SliceSource{Slice: signal}, 8000, nil)
+	var segments []Segment
+	for {
+		seg, err := source.NextSegment()
+		if err != nil {
+			break
+		}
+		segments = append(segments, seg)
+	}
+	hangover := 2400
+	expected := []Segment{{0, 2000 + hangover}, {10000, 12400 + hangover}}
+	if len(segments) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, segments)
+	}
+	for i, seg := range segments {
+		if abs := math.Abs(float64(seg.Start - expected[i].Start)); abs > 80 {
+			t.Errorf("segment %d: expected start %d got %d", i, expected[i].Start, seg.Start)
+		}
+		if abs := math.Abs(float64(seg.End - expected[i].End)); abs > 80 {
+			t.Errorf("segment %d: expected end %d got %d", i, expected[i].End, seg.End)
+		}
+	}
+
+	trimmed := readAllSamples(t, TrimSilence(&SliceSource{Slice: signal}, 8000, nil))
+	if len(trimmed) == 0 || trimmed[0] != signal[0] {
+		t.Error("trimmed audio should start with the initial speech")
+	}
+
+	// Audio shorter than the initial window is still
+	// classified.
+	short := vadTestSignal()[3000:7000]
+	source = SpeechSegments(&SliceSource{Slice: short}, 8000, nil)
+	if seg, err := source.NextSegment(); err != nil || math.Abs(float64(seg.Start-1000)) > 80 {
+		t.Errorf("short audio: got segment %v (%v)", seg, err)
+	}
+}
+
+func TestTrimSilence(t *testing.T) {
+	signal := vadTestSignal()
+	opts := &VADOptions{Hangover: 200 * time.Millisecond}
+	segments := SpeechSegments(&SliceSource{Slice: signal}, 8000, opts)
+	first, _ := segments.NextSegment()
+	last := first
+	for {
+		seg, err := segments.NextSegment()
+		if err != nil {
+			break
+		}
+		last = seg
+	}
+	expected := signal[first.Start:last.End]
+
+	for _, buffSize := range []int{1, 37, 1000} {
+		trimmed := TrimSilence(&sliceSource{vec: signal, buffSize: buffSize}, 8000, opts)
+		actual := readAllSamples(t, trimmed)
+		if len(actual) != len(expected) {
+			t.Errorf("buffer %d: expected %d samples but got %d", buffSize, len(expected),
+				len(actual))
+		} else if !slicesClose(actual, expected) {
+			t.Errorf("buffer %d: unexpected samples", buffSize)
+		}
+	}
+
+	silence := make([]float64, 5000)
+	if res := readAllSamples(t, TrimSilence(&SliceSource{Slice: silence}, 8000, nil)); len(res) != 0 {
+		t.Errorf("expected no samples but got %d", len(res))
+	}
+}
+
+// vadTestSignal generates quiet noise with loud bursts
+// at samples 4000-8000, 10000-12000 and 20000-22400.
+func vadTestSignal() []float64 {
+	gen := rand.New(rand.NewSource(1337))
+	signal := make([]float64, 28000)
+	for i := range signal {
+		signal[i] = gen.NormFloat64() * 0.001
+		if (i >= 4000 && i < 8000) || (i >= 10000 && i < 12000) ||
+			(i >= 20000 && i < 22400) {
+			signal[i] += 0.3 * math.Sin(float64(i)*0.2)
+		}
+	}
+	return signal
+}
@@ -7,63 +7,70 @@ package mfcc
 // For example, for input coefficients [a,b,c], the
 // resulting source would produce coefficients
 // [a,b,c,da,db,dc] where d stands for derivative.
-func AddVelocities(c CoeffSource) CoeffSource {
+func AddVelocities(c CoeffSource) FrameSource {
 	return &velocitySource{
-		Wrapped: c,
+		Wrapped: Frames(c),
 	}
 }
 
 type velocitySource struct {
-	Wrapped CoeffSource
+	Wrapped FrameSource
 
-	last      []float64
-	lastLast  []float64
+	last      *Frame
+	lastLast  *Frame
 	doneError error
 }
 
 func (v *velocitySource) NextCoeffs() ([]float64, error) {
+	return coeffsOf(v.NextFrame())
+}
+
+func (v *velocitySource) NextFrame() (*Frame, error) {
 	if v.doneError != nil {
 		return nil, v.doneError
 	}
 
 	if v.last == nil {
-		v.lastLast, v.doneError = v.Wrapped.NextCoeffs()
+		v.lastLast, v.doneError = v.Wrapped.NextFrame()
 		if v.doneError != nil {
 			return nil, v.doneError
 		}
-		v.last, v.doneError = v.Wrapped.NextCoeffs()
+		first := v.lastLast.Coeffs
+		v.last, v.doneError = v.Wrapped.NextFrame()
 		if v.doneError != nil {
-			augmented := make([]float64, len(v.lastLast)*2)
-			copy(augmented, v.lastLast)
-			return augmented, nil
+			augmented := make([]float64, len(first)*2)
+			copy(augmented, first)
+			return v.lastLast.withCoeffs(augmented), nil
 		}
-		res := make([]float64, len(v.lastLast)*2)
-		copy(res, v.lastLast)
-		for i, x := range v.lastLast {
-			res[i+len(v.lastLast)] = v.last[i] - x
+		res := make([]float64, len(first)*2)
+		copy(res, first)
+		for i, x := range first {
+			res[i+len(first)] = v.last.Coeffs[i] - x
 		}
-		return res, nil
+		return v.lastLast.withCoeffs(res), nil
 	}
 
-	var next []float64
-	next, v.doneError = v.Wrapped.NextCoeffs()
+	var next *Frame
+	next, v.doneError = v.Wrapped.NextFrame()
+	last := v.last.Coeffs
 	if v.doneError != nil {
-		res := make([]float64, len(v.last)*2)
-		copy(res, v.last)
-		for i, x := range v.lastLast {
-			res[i+len(v.last)] = v.last[i] - x
+		res := make([]float64, len(last)*2)
+		copy(res, last)
+		for i, x := range v.lastLast.Coeffs {
+			res[i+len(last)] = last[i] - x
 		}
-		return res, nil
+		return v.last.withCoeffs(res), nil
 	}
 
-	midpointRes := make([]float64, len(v.last)*2)
-	copy(midpointRes, v.last)
-	for i, x := range v.lastLast {
-		midpointRes[i+len(v.last)] = (next[i] - x) / 2
+	midpointRes := make([]float64, len(last)*2)
+	copy(midpointRes, last)
+	for i, x := range v.lastLast.Coeffs {
+		midpointRes[i+len(last)] = (next.Coeffs[i] - x) / 2
 	}
+	res := v.last.withCoeffs(midpointRes)
 
 	v.lastLast = v.last
 	v.last = next
 
-	return midpointRes, nil
+	return res, nil
 }
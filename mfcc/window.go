@@ -0,0 +1,97 @@
+package mfcc
+
+import (
+	"math"
+	"sync"
+)
+
+// A WindowFunc generates the coefficients of an analysis
+// window with n samples.
+// Each frame of audio is multiplied element-wise by the
+// window before its spectrum is computed.
+type WindowFunc func(n int) []float64
+
+// HammingWindow generates a Hamming window.
+func HammingWindow(n int) []float64 {
+	return cosineWindow(n, 0.54, 0.46, 0)
+}
+
+// HannWindow generates a Hann (raised cosine) window.
+func HannWindow(n int) []float64 {
+	return cosineWindow(n, 0.5, 0.5, 0)
+}
+
+// BlackmanWindow generates a Blackman window.
+func BlackmanWindow(n int) []float64 {
+	return cosineWindow(n, 0.42, 0.5, 0.08)
+}
+
+// PoveyWindow generates the window used by default in
+// Kaldi, which is a Hann window raised to the power of
+// 0.85.
+// It is similar to a Hamming window, but it goes to zero
+// at the edges.
+func PoveyWindow(n int) []float64 {
+	res := HannWindow(n)
+	for i, x := range res {
+		res[i] = math.Pow(x, 0.85)
+	}
+	return res
+}
+
+// cosineWindow generates a window of the form
+// a0 - a1*cos(2*pi*i/(n-1)) + a2*cos(4*pi*i/(n-1)).
+func cosineWindow(n int, a0, a1, a2 float64) []float64 {
+	res := make([]float64, n)
+	if n == 1 {
+		res[0] = 1
+		return res
+	}
+	baseFreq := 2 * math.Pi / float64(n-1)
+	for i := range res {
+		arg := baseFreq * float64(i)
+		res[i] = a0 - a1*math.Cos(arg) + a2*math.Cos(2*arg)
+	}
+	return res
+}
+
+// windowCache lazily computes and caches the coefficients
+// of a WindowFunc for each window size it is asked for.
+//
+// A windowCache with a nil Func represents a rectangular
+// window, in which case no coefficients are computed.
+type windowCache struct {
+	Func WindowFunc
+
+	lock  sync.Mutex
+	cache map[int][]float64
+}
+
+// Coeffs returns the window coefficients for size n.
+// The result should not be modified by the caller.
+func (w *windowCache) Coeffs(n int) []float64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if res, ok := w.cache[n]; ok {
+		return res
+	}
+	if w.cache == nil {
+		w.cache = map[int][]float64{}
+	}
+	res := w.Func(n)
+	if len(res) != n {
+		panic("window function returned wrong number of coefficients")
+	}
+	w.cache[n] = res
+	return res
+}
+
+// Apply multiplies the samples in place by the window.
+func (w *windowCache) Apply(samples []float64) {
+	if w.Func == nil {
+		return
+	}
+	for i, c := range w.Coeffs(len(samples)) {
+		samples[i] *= c
+	}
+}
@@ -0,0 +1,55 @@
+package mfcc
+
+import "testing"
+
+func TestWindowFuncs(t *testing.T) {
+	funcs := []WindowFunc{HammingWindow, HannWindow, BlackmanWindow, PoveyWindow}
+	outputs := [][]float64{
+		[]float64{0.08, 0.54, 1, 0.54, 0.08},
+		[]float64{0, 0.5, 1, 0.5, 0},
+		[]float64{0, 0.34, 1, 0.34, 0},
+		[]float64{0, 0.554784736, 1, 0.554784736, 0},
+	}
+	for i, f := range funcs {
+		actual := f(5)
+		expected := outputs[i]
+		if len(actual) != len(expected) {
+			t.Errorf("%d: expected len %d got len %d", i, len(expected), len(actual))
+		} else if !slicesClose(actual, expected) {
+			t.Errorf("%d: expected %v got %v", i, expected, actual)
+		}
+		if single := f(1); len(single) != 1 || single[0] != 1 {
+			t.Errorf("%d: bad single-sample window %v", i, single)
+		}
+	}
+}
+
+func TestWindowCache(t *testing.T) {
+	var calls int
+	cache := &windowCache{
+		Func: func(n int) []float64 {
+			calls++
+			return HannWindow(n)
+		},
+	}
+	for i := 0; i < 3; i++ {
+		cache.Coeffs(5)
+		cache.Coeffs(8)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls but got %d", calls)
+	}
+
+	samples := []float64{1, 2, 3, 4, 5}
+	cache.Apply(samples)
+	expected := []float64{0, 1, 3, 2, 0}
+	if !slicesClose(samples, expected) {
+		t.Errorf("expected %v got %v", expected, samples)
+	}
+
+	samples = []float64{1, 2, 3}
+	(&windowCache{}).Apply(samples)
+	if !slicesClose(samples, []float64{1, 2, 3}) {
+		t.Errorf("rectangular window changed samples: %v", samples)
+	}
+}
@@ -0,0 +1,121 @@
+package pitch
+
+import "math"
+
+// nccfOctaveRatio is the fraction of the highest
+// correlation which a shorter lag must reach to be chosen
+// instead.
+const nccfOctaveRatio = 0.9
+
+// yin estimates the period of a window of audio, in
+// samples, using the YIN algorithm.
+// The window must have 2*maxLag samples.
+//
+// It returns the period and a voicing probability.
+func yin(window []float64, minLag, maxLag int, threshold float64) (lag, voicing float64) {
+	size := len(window) - maxLag
+
+	// cmnd is the cumulative mean normalized difference.
+	cmnd := make([]float64, maxLag+1)
+	cmnd[0] = 1
+	var total float64
+	for tau := 1; tau <= maxLag; tau++ {
+		var diff float64
+		for j := 0; j < size; j++ {
+			d := window[j] - window[j+tau]
+			diff += d * d
+		}
+		total += diff
+		if total == 0 {
+			cmnd[tau] = 1
+		} else {
+			cmnd[tau] = diff * float64(tau) / total
+		}
+	}
+
+	best := -1
+	for tau := minLag; tau <= maxLag; tau++ {
+		if cmnd[tau] < threshold {
+			for tau < maxLag && cmnd[tau+1] < cmnd[tau] {
+				tau++
+			}
+			best = tau
+			break
+		}
+	}
+	if best < 0 {
+		best = minLag
+		for tau := minLag + 1; tau <= maxLag; tau++ {
+			if cmnd[tau] < cmnd[best] {
+				best = tau
+			}
+		}
+	}
+
+	voicing = math.Max(0, math.Min(1, 1-cmnd[best]))
+	return refinePeak(cmnd, best, minLag, maxLag), voicing
+}
+
+// nccf estimates the period of a window of audio, in
+// samples, by maximizing the normalized cross-correlation
+// between the window and a delayed copy of itself.
+// The window must have 2*maxLag samples.
+//
+// It returns the period and a voicing probability.
+func nccf(window []float64, minLag, maxLag int) (lag, voicing float64) {
+	size := len(window) - maxLag
+
+	var startEnergy float64
+	for _, x := range window[:size] {
+		startEnergy += x * x
+	}
+
+	corrs := make([]float64, maxLag+1)
+	for tau := minLag; tau <= maxLag; tau++ {
+		var dot, energy float64
+		for j := 0; j < size; j++ {
+			dot += window[j] * window[j+tau]
+			energy += window[j+tau] * window[j+tau]
+		}
+		if denom := math.Sqrt(startEnergy * energy); denom > 0 {
+			corrs[tau] = dot / denom
+		}
+	}
+
+	best := minLag
+	for tau := minLag + 1; tau <= maxLag; tau++ {
+		if corrs[tau] > corrs[best] {
+			best = tau
+		}
+	}
+
+	// Multiples of the period correlate about as well as
+	// the period itself, so the shortest lag with a peak
+	// close to the best one is preferred.
+	for tau := minLag + 1; tau < best; tau++ {
+		if corrs[tau] >= nccfOctaveRatio*corrs[best] && corrs[tau] >= corrs[tau-1] &&
+			corrs[tau] >= corrs[tau+1] {
+			best = tau
+			break
+		}
+	}
+
+	voicing = math.Max(0, math.Min(1, corrs[best]))
+	return refinePeak(corrs, best, minLag, maxLag), voicing
+}
+
+// refinePeak fits a parabola through an extremum of a
+// function and its neighbors to estimate the location of
+// the true extremum between samples.
+func refinePeak(f []float64, idx, minIdx, maxIdx int) float64 {
+	if idx <= minIdx || idx >= maxIdx {
+		return float64(idx)
+	}
+	y0, y1, y2 := f[idx-1], f[idx], f[idx+1]
+	denom := y0 - 2*y1 + y2
+	if denom == 0 {
+		return float64(idx)
+	}
+	offset := (y0 - y2) / (2 * denom)
+	return float64(idx) + math.Max(-0.5, math.Min(0.5, offset))
+}
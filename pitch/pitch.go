@@ -0,0 +1,224 @@
+// Package pitch estimates the fundamental frequency (F0)
+// of speech on the same frames that package mfcc uses,
+// so that pitch features can be appended to MFCCs.
+//
+// For example, to append pitch features to MFCCs:
+//
+//	joined := pitch.MFCCWithPitch(source, rate, opts, nil)
+package pitch
+
+import "github.com/unixpickle/speechrecog/mfcc"
+
+const (
+	DefaultMinFreq      = 50
+	DefaultMaxFreq      = 400
+	DefaultYINThreshold = 0.1
+)
+
+// Method is an algorithm for estimating F0.
+type Method int
+
+const (
+	// YIN uses the cumulative mean normalized difference
+	// function from de Cheveigné and Kawahara (2002).
+	YIN Method = iota
+
+	// NCCF picks the lag which maximizes the normalized
+	// cross-correlation function.
+	NCCF
+)
+
+// Options stores configuration options for estimating
+// pitch.
+type Options struct {
+	// MinFreq is the lowest F0 which can be detected.
+	// It also determines the size of the analysis window,
+	// which spans two periods at this frequency.
+	// If this is 0, DefaultMinFreq is used.
+	MinFreq float64
+
+	// MaxFreq is the highest F0 which can be detected.
+	// If this is 0, DefaultMaxFreq is used.
+	MaxFreq float64
+
+	// Method is the estimation algorithm.
+	// The default is YIN.
+	Method Method
+
+	// Threshold is the absolute threshold for YIN.
+	// Smaller values avoid octave errors in clean audio,
+	// but miss more periods in noisy audio.
+	// If this is 0, DefaultYINThreshold is used.
+	Threshold float64
+}
+
+// setDefaults replaces zero fields with their defaults.
+func (o *Options) setDefaults() {
+	if o.MinFreq == 0 {
+		o.MinFreq = DefaultMinFreq
+	}
+	if o.MaxFreq == 0 {
+		o.MaxFreq = DefaultMaxFreq
+	}
+	if o.Threshold == 0 {
+		o.Threshold = DefaultYINThreshold
+	}
+}
+
+// NewSource generates a FrameSource that estimates the
+// pitch of each frame that mfcc.MFCC would produce for the
+// same audio, sampleRate and frameOptions.
+//
+// Every vector has two entries: the estimated F0 in Hertz
+// and the probability that the frame is voiced, between 0
+// and 1.
+// An F0 is estimated for every frame, even unvoiced ones,
+// so that it can be used directly as a network input.
+//
+// The analysis window is centered on the MFCC frame, but
+// it is usually longer than the frame, so each output is
+// delayed until the audio for its window has been read.
+func NewSource(source mfcc.Source, sampleRate int, frameOptions *mfcc.Options,
+	options *Options) mfcc.FrameSource {
+	if frameOptions == nil {
+		frameOptions = &mfcc.Options{}
+	}
+	opts := Options{}
+	if options != nil {
+		opts = *options
+	}
+	opts.setDefaults()
+	layout := mfcc.NewFrameLayout(sampleRate, frameOptions)
+	if !frameOptions.NativeSampleRate {
+		source = mfcc.Resample(source, float64(layout.SampleRate)/float64(sampleRate),
+			frameOptions.Resampling)
+	}
+
+	rate := float64(layout.SampleRate)
+	minLag := int(rate / opts.MaxFreq)
+	if minLag < 1 {
+		minLag = 1
+	}
+	maxLag := int(rate/opts.MinFreq) + 1
+	if maxLag <= minLag {
+		maxLag = minLag + 1
+	}
+
+	return &pitchSource{
+		source:    source,
+		layout:    layout,
+		method:    opts.Method,
+		threshold: opts.Threshold,
+		minLag:    minLag,
+		maxLag:    maxLag,
+	}
+}
+
+// MFCCWithPitch generates a FrameSource which appends
+// pitch features from NewSource to the MFCCs of the same
+// audio.
+//
+// The audio is only read once, so source may be a
+// stream rather than a file which can be decoded twice.
+func MFCCWithPitch(source mfcc.Source, sampleRate int, frameOptions *mfcc.Options,
+	options *Options) mfcc.FrameSource {
+	sources := mfcc.Tee(source, 2)
+	coeffs := mfcc.MFCC(sources[0], sampleRate, frameOptions)
+	pitches := NewSource(sources[1], sampleRate, frameOptions, options)
+	return mfcc.JoinCoeffs(coeffs, pitches)
+}
+
+type pitchSource struct {
+	source    mfcc.Source
+	layout    mfcc.FrameLayout
+	method    Method
+	threshold float64
+	minLag    int
+	maxLag    int
+
+	// buffer stores the samples starting at bufferStart.
+	buffer      []float64
+	bufferStart int
+	doneError   error
+
+	frameIdx int
+}
+
+func (p *pitchSource) NextCoeffs() ([]float64, error) {
+	frame, err := p.NextFrame()
+	if err != nil {
+		return nil, err
+	}
+	return frame.Coeffs, nil
+}
+
+func (p *pitchSource) NextFrame() (*mfcc.Frame, error) {
+	// The analysis window spans maxLag samples on either
+	// side of the center of the frame.
+	center := p.frameIdx*p.layout.Step + p.layout.FrameSize/2
+	start := center - p.maxLag
+	end := center + p.maxLag
+
+	// Enough audio must be read to know that the frame
+	// exists, which means that the previous frame is full.
+	need := end
+	if p.frameIdx > 0 {
+		if prevEnd := (p.frameIdx-1)*p.layout.Step + p.layout.FrameSize; prevEnd > need {
+			need = prevEnd
+		}
+	}
+	for p.doneError == nil && p.bufferStart+len(p.buffer) < need {
+		var chunk [512]float64
+		n, err := p.source.ReadSamples(chunk[:])
+		p.buffer = append(p.buffer, chunk[:n]...)
+		if err != nil {
+			p.doneError = err
+		}
+	}
+	frameStart := p.frameIdx * p.layout.Step
+	frameEnd := frameStart + p.layout.FrameSize
+	if p.doneError != nil {
+		total := p.bufferStart + len(p.buffer)
+		if p.frameIdx >= p.layout.FrameCount(total) {
+			return nil, p.doneError
+		}
+		if total < frameEnd {
+			frameEnd = total
+		}
+	}
+
+	window := make([]float64, end-start)
+	for i := range window {
+		idx := start + i - p.bufferStart
+		if idx >= 0 && idx < len(p.buffer) {
+			window[i] = p.buffer[idx]
+		}
+	}
+
+	rate := float64(p.layout.SampleRate)
+	var lag, voicing float64
+	if p.method == NCCF {
+		lag, voicing = nccf(window, p.minLag, p.maxLag)
+	} else {
+		lag, voicing = yin(window, p.minLag, p.maxLag, p.threshold)
+	}
+
+	frame := &mfcc.Frame{
+		Index:  p.frameIdx,
+		Start:  p.layout.SampleTime(frameStart),
+		End:    p.layout.SampleTime(frameEnd),
+		Coeffs: []float64{rate / lag, voicing},
+	}
+
+	p.frameIdx++
+	nextStart := p.frameIdx*p.layout.Step + p.layout.FrameSize/2 - p.maxLag
+	if drop := nextStart - p.bufferStart; drop > 0 {
+		if drop > len(p.buffer) {
+			drop = len(p.buffer)
+		}
+		p.buffer = append([]float64{}, p.buffer[drop:]...)
+		p.bufferStart += drop
+	}
+
+	return frame, nil
+}
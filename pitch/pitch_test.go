@@ -0,0 +1,147 @@
+package pitch
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/unixpickle/speechrecog/mfcc"
+)
+
+func TestPitchTones(t *testing.T) {
+	for _, method := range []Method{YIN, NCCF} {
+		for _, freq := range []float64{110, 220, 310} {
+			signal := harmonicTone(freq, 16000, 8000)
+			frames := readAll(t, NewSource(&mfcc.SliceSource{Slice: signal}, 16000,
+				&mfcc.Options{NativeSampleRate: true}, &Options{Method: method}))
+			for i := 5; i < len(frames)-5; i++ {
+				if math.Abs(frames[i][0]-freq) > freq*0.01 {
+					t.Errorf("method %d freq %f frame %d: got F0 %f", method, freq, i,
+						frames[i][0])
+				}
+				if frames[i][1] < 0.8 {
+					t.Errorf("method %d freq %f frame %d: got voicing %f", method, freq, i,
+						frames[i][1])
+				}
+			}
+		}
+	}
+}
+
+func TestPitchNoise(t *testing.T) {
+	rand.Seed(123)
+	signal := make([]float64, 8000)
+	for i := range signal {
+		signal[i] = rand.NormFloat64()
+	}
+	for _, method := range []Method{YIN, NCCF} {
+		frames := readAll(t, NewSource(&mfcc.SliceSource{Slice: signal}, 16000,
+			&mfcc.Options{NativeSampleRate: true}, &Options{Method: method}))
+		var mean float64
+		for _, frame := range frames {
+			mean += frame[1] / float64(len(frames))
+		}
+		if mean > 0.5 {
+			t.Errorf("method %d: mean voicing of noise is %f", method, mean)
+		}
+	}
+}
+
+func TestPitchFrameGrid(t *testing.T) {
+	optionList := []*mfcc.Options{
+		nil,
+		{NativeSampleRate: true},
+		{NativeSampleRate: true, Window: 25 * time.Millisecond, DisableOverlap: true},
+	}
+	for i, opts := range optionList {
+		for _, count := range []int{0, 100, 4000, 4321} {
+			signal := harmonicTone(200, 8000, count)
+			expected := readAll(t, mfcc.MFCC(&mfcc.SliceSource{Slice: signal}, 8000, opts))
+			pitches := NewSource(&mfcc.SliceSource{Slice: signal}, 8000, opts, nil)
+			actual := readAll(t, pitches)
+			if len(actual) != len(expected) {
+				t.Errorf("options %d, %d samples: expected %d frames but got %d", i, count,
+					len(expected), len(actual))
+			}
+			checkFrameTimes(t, mfcc.MFCC(&mfcc.SliceSource{Slice: signal}, 8000, opts),
+				NewSource(&mfcc.SliceSource{Slice: signal}, 8000, opts, nil))
+			joined := readAll(t, mfcc.JoinCoeffs(
+				mfcc.MFCC(&mfcc.SliceSource{Slice: signal}, 8000, opts),
+				NewSource(&mfcc.SliceSource{Slice: signal}, 8000, opts, nil),
+			))
+			for j, frame := range joined {
+				if len(frame) != mfcc.DefaultKeepCount+2 {
+					t.Fatalf("options %d frame %d: bad size %d", i, j, len(frame))
+				}
+			}
+			single := readAll(t, MFCCWithPitch(&mfcc.SliceSource{Slice: signal}, 8000,
+				opts, nil))
+			if len(single) != len(joined) {
+				t.Errorf("options %d, %d samples: expected %d frames but got %d", i, count,
+					len(joined), len(single))
+			}
+			for j, frame := range single {
+				if j < len(joined) && !vectorsEqual(frame, joined[j]) {
+					t.Errorf("options %d frame %d: expected %v got %v", i, j, joined[j], frame)
+				}
+			}
+		}
+	}
+}
+
+func checkFrameTimes(t *testing.T, expected, actual mfcc.FrameSource) {
+	for {
+		x, err1 := expected.NextFrame()
+		a, err2 := actual.NextFrame()
+		if err1 != nil || err2 != nil {
+			if err1 != err2 {
+				t.Errorf("expected error %v but got %v", err1, err2)
+			}
+			return
+		}
+		if a.Index != x.Index || a.Start != x.Start || a.End != x.End {
+			t.Errorf("frame %d should be [%v, %v] but got %d [%v, %v]", x.Index,
+				x.Start, x.End, a.Index, a.Start, a.End)
+		}
+	}
+}
+
+func vectorsEqual(v1, v2 []float64) bool {
+	if len(v1) != len(v2) {
+		return false
+	}
+	for i, x := range v1 {
+		if v2[i] != x {
+			return false
+		}
+	}
+	return true
+}
+
+// harmonicTone generates a tone with several harmonics,
+// like a voiced speech sound.
+func harmonicTone(freq float64, rate, count int) []float64 {
+	res := make([]float64, count)
+	for i := range res {
+		t := float64(i) / float64(rate)
+		for h := 1; h <= 5; h++ {
+			res[i] += math.Sin(2*math.Pi*freq*float64(h)*t) / float64(h)
+		}
+	}
+	return res
+}
+
+func readAll(t *testing.T, c mfcc.CoeffSource) [][]float64 {
+	var res [][]float64
+	for {
+		vec, err := c.NextCoeffs()
+		if err == io.EOF {
+			return res
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, vec)
+	}
+}
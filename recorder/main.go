@@ -14,7 +14,10 @@ import (
 
 func main() {
 	var portNum int
+	var trim, normalize bool
 	flag.IntVar(&portNum, "port", 80, "HTTP port number")
+	flag.BoolVar(&trim, "trim", false, "trim silence from recordings (stores mono audio)")
+	flag.BoolVar(&normalize, "normalize", false, "normalize recording volume (stores mono audio)")
 
 	flag.Parse()
 
@@ -47,6 +50,8 @@ func main() {
 	}
 
 	http.ListenAndServe(":"+strconv.Itoa(portNum), &Server{
-		Index: index,
+		Index:     index,
+		Trim:      trim,
+		Normalize: normalize,
 	})
 }
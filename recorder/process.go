@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io"
+	"math"
+	"os"
+
+	"github.com/unixpickle/speechrecog/mfcc"
+	"github.com/unixpickle/speechrecog/wavio"
+)
+
+// NormalizedPeak is the peak amplitude of normalized
+// recordings, leaving some headroom below clipping.
+const NormalizedPeak = 0.9
+
+// storeRecording saves an uploaded WAV file to a path.
+//
+// If the server trims or normalizes recordings, the
+// audio is mixed down to a single channel and stored as
+// 16-bit PCM.
+// Otherwise, the file is stored exactly as it was
+// uploaded.
+func (s *Server) storeRecording(path string, data io.Reader) error {
+	if !s.Trim && !s.Normalize {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(f, data)
+		return err
+	}
+
+	reader, err := wavio.NewReader(data)
+	if err != nil {
+		return err
+	}
+	source := mfcc.Downmix(reader, reader.Channels())
+	if s.Trim {
+		source = mfcc.TrimSilence(source, reader.SampleRate(), nil)
+	}
+	if s.Normalize {
+		var samples mfcc.SliceSink
+		if _, err := mfcc.Copy(&samples, source); err != nil {
+			return err
+		}
+		normalizePeak(samples.Slice)
+		source = &mfcc.SliceSource{Slice: samples.Slice}
+	}
+
+	w, err := wavio.Create(path, wavio.Format{
+		SampleRate:    reader.SampleRate(),
+		Channels:      1,
+		BitsPerSample: 16,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := mfcc.Copy(w, source); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func normalizePeak(samples []float64) {
+	var peak float64
+	for _, x := range samples {
+		peak = math.Max(peak, math.Abs(x))
+	}
+	if peak == 0 {
+		return
+	}
+	for i := range samples {
+		samples[i] *= NormalizedPeak / peak
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/hex"
 	"errors"
 	"html/template"
-	"io"
 	"math/rand"
 	"net/http"
 	"os"
@@ -40,6 +39,14 @@ func init() {
 type Server struct {
 	DataLock sync.RWMutex
 	Index    *speechdata.Index
+
+	// Trim indicates that silence should be trimmed from
+	// the start and end of uploaded recordings.
+	Trim bool
+
+	// Normalize indicates that uploaded recordings should
+	// be scaled to a consistent peak level.
+	Normalize bool
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -160,13 +167,8 @@ func (s *Server) upload(r *http.Request) error {
 	destName := randomID()
 	destPath := filepath.Join(s.Index.DirPath, destName)
 
-	f, err := os.Create(destPath)
-	defer f.Close()
-	if err != nil {
-		return err
-	}
 	b64 := base64.NewDecoder(base64.StdEncoding, r.Body)
-	if _, err := io.Copy(f, b64); err != nil {
+	if err := s.storeRecording(destPath, b64); err != nil {
 		os.Remove(destPath)
 		return err
 	}
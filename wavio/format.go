@@ -0,0 +1,52 @@
+// Package wavio reads and writes audio files in a
+// streaming fashion, so that long recordings can be
+// processed in constant memory.
+//
+// Readers implement mfcc.Source and Writers implement
+// mfcc.Sink, both using interleaved samples between -1
+// and 1.
+// To get a single channel, wrap a Reader with
+// mfcc.Downmix or mfcc.SelectChannel.
+package wavio
+
+import "errors"
+
+// Format describes how samples are encoded.
+type Format struct {
+	SampleRate int
+	Channels   int
+
+	// BitsPerSample is 8, 16, 24 or 32.
+	BitsPerSample int
+
+	// Float indicates that samples are IEEE floating
+	// point numbers rather than integers.
+	// Only 32-bit floating point samples are supported.
+	Float bool
+}
+
+// sampleSize returns the number of bytes per sample.
+func (f Format) sampleSize() int {
+	return f.BitsPerSample / 8
+}
+
+func (f Format) validate() error {
+	if f.SampleRate <= 0 {
+		return errors.New("invalid sample rate")
+	}
+	if f.Channels <= 0 {
+		return errors.New("invalid channel count")
+	}
+	if f.Float {
+		if f.BitsPerSample != 32 {
+			return errors.New("unsupported float sample size")
+		}
+		return nil
+	}
+	switch f.BitsPerSample {
+	case 8, 16, 24, 32:
+		return nil
+	default:
+		return errors.New("unsupported PCM sample size")
+	}
+}
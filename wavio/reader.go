@@ -0,0 +1,239 @@
+package wavio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// WAVE format tags.
+const (
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xfffe
+)
+
+// unknownDataSize is the data chunk size written by
+// programs which stream WAV files without seeking back
+// to fill in the header.
+const unknownDataSize = 0xffffffff
+
+// Limits on the size of fmt chunks.
+// Only the first formatFieldsSize bytes are used, and
+// larger chunks than maxFormatSize are assumed to be
+// corrupt rather than allocated.
+const (
+	formatFieldsSize = 40
+	maxFormatSize    = 1024
+)
+
+// A Reader decodes samples from an io.Reader.
+// It implements mfcc.Source.
+type Reader struct {
+	r      io.Reader
+	format Format
+
+	// remaining is the number of bytes left in the data,
+	// or -1 if the data continues until io.EOF.
+	remaining int64
+
+	buffer    []byte
+	doneError error
+}
+
+// NewReader creates a Reader for a RIFF/WAVE stream.
+// It reads the header up to the start of the sample data,
+// skipping any chunks other than "fmt " and "data".
+//
+// If the data chunk's size is 0xFFFFFFFF, as written by
+// some streaming encoders, samples are read until the
+// underlying reader returns io.EOF.
+func NewReader(r io.Reader) (*Reader, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, headerError(err)
+	}
+	if string(riff[:4]) != "RIFF" || string(riff[8:]) != "WAVE" {
+		return nil, errors.New("not a RIFF/WAVE file")
+	}
+
+	var format *Format
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, headerError(err)
+		}
+		id := string(header[:4])
+		size := binary.LittleEndian.Uint32(header[4:])
+		switch id {
+		case "fmt ":
+			if size > maxFormatSize {
+				return nil, errors.New("fmt chunk too large")
+			}
+			var chunk [formatFieldsSize]byte
+			used := chunk[:]
+			if size < formatFieldsSize {
+				used = chunk[:size]
+			}
+			if _, err := io.ReadFull(r, used); err != nil {
+				return nil, headerError(err)
+			}
+			skip := int64(size) + int64(size%2) - int64(len(used))
+			if _, err := io.CopyN(ioutil.Discard, r, skip); err != nil {
+				return nil, headerError(err)
+			}
+			f, err := parseFormatChunk(used)
+			if err != nil {
+				return nil, err
+			}
+			format = &f
+		case "data":
+			if format == nil {
+				return nil, errors.New("data chunk before fmt chunk")
+			}
+			res := NewRawReader(r, *format)
+			if size != unknownDataSize {
+				res.remaining = int64(size)
+			}
+			return res, nil
+		default:
+			skip := int64(size) + int64(size%2)
+			if _, err := io.CopyN(ioutil.Discard, r, skip); err != nil {
+				return nil, headerError(err)
+			}
+		}
+	}
+}
+
+// NewRawReader creates a Reader for headerless,
+// little-endian samples, such as audio piped from
+// another program.
+// Samples are read until r returns io.EOF.
+//
+// This panics if the format is invalid or not
+// supported.
+func NewRawReader(r io.Reader, format Format) *Reader {
+	if err := format.validate(); err != nil {
+		panic(err)
+	}
+	return &Reader{r: r, format: format, remaining: -1}
+}
+
+// Format returns the encoding of the samples.
+func (r *Reader) Format() Format {
+	return r.format
+}
+
+// SampleRate returns the number of samples per second
+// in each channel.
+func (r *Reader) SampleRate() int {
+	return r.format.SampleRate
+}
+
+// Channels returns the number of interleaved channels.
+func (r *Reader) Channels() int {
+	return r.format.Channels
+}
+
+// ReadSamples reads interleaved samples, scaled to the
+// range [-1, 1].
+//
+// If the audio ends in the middle of a sample, or before
+// the end of the data chunk, io.ErrUnexpectedEOF is
+// returned.
+func (r *Reader) ReadSamples(s []float64) (n int, err error) {
+	if r.doneError != nil {
+		return 0, r.doneError
+	}
+	if len(s) == 0 {
+		return 0, nil
+	}
+	size := r.format.sampleSize()
+	want := len(s) * size
+	if r.remaining >= 0 && int64(want) > r.remaining {
+		want = int(r.remaining)
+	}
+	if len(r.buffer) < want {
+		r.buffer = make([]byte, want)
+	}
+	buf := r.buffer[:want]
+
+	got, err := io.ReadFull(r.r, buf)
+	if r.remaining >= 0 {
+		r.remaining -= int64(got)
+	}
+	n = got / size
+	for i := 0; i < n; i++ {
+		s[i] = r.decode(buf[i*size:])
+	}
+
+	if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF {
+		// A sized data chunk may end in a partial sample
+		// without the underlying reader failing.
+		if got%size != 0 || (err != nil && r.remaining > 0) {
+			err = io.ErrUnexpectedEOF
+		} else if err != nil || r.remaining == 0 {
+			err = io.EOF
+		}
+	}
+	if err != nil {
+		r.doneError = err
+	}
+	return
+}
+
+func (r *Reader) decode(b []byte) float64 {
+	if r.format.Float {
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+	switch r.format.BitsPerSample {
+	case 8:
+		return (float64(b[0]) - 128) / 128
+	case 16:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case 24:
+		x := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float64(x) / (1 << 23)
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+	}
+}
+
+func parseFormatChunk(chunk []byte) (Format, error) {
+	if len(chunk) < 16 {
+		return Format{}, errors.New("fmt chunk too short")
+	}
+	tag := binary.LittleEndian.Uint16(chunk)
+	if tag == formatExtensible {
+		// The real tag is the start of the sub-format GUID.
+		if len(chunk) < 26 {
+			return Format{}, errors.New("extensible fmt chunk too short")
+		}
+		tag = binary.LittleEndian.Uint16(chunk[24:])
+	}
+	res := Format{
+		Channels:      int(binary.LittleEndian.Uint16(chunk[2:])),
+		SampleRate:    int(binary.LittleEndian.Uint32(chunk[4:])),
+		BitsPerSample: int(binary.LittleEndian.Uint16(chunk[14:])),
+	}
+	switch tag {
+	case formatPCM:
+	case formatFloat:
+		res.Float = true
+	default:
+		return Format{}, errors.New("unsupported WAVE format tag")
+	}
+	if err := res.validate(); err != nil {
+		return Format{}, err
+	}
+	return res, nil
+}
+
+func headerError(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
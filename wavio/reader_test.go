@@ -0,0 +1,257 @@
+package wavio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/unixpickle/speechrecog/mfcc"
+)
+
+var _ mfcc.Source = &Reader{}
+
+func TestReaderFormats(t *testing.T) {
+	tests := []struct {
+		tag      uint16
+		bits     int
+		data     []byte
+		expected []float64
+	}{
+		{formatPCM, 8, []byte{0, 128, 192}, []float64{-1, 0, 0.5}},
+		{formatPCM, 16, []byte{0, 0x80, 0, 0x40, 0xff, 0xff}, []float64{-1, 0.5, -1.0 / 32768}},
+		{formatPCM, 24, []byte{0, 0, 0xc0, 0, 0, 0x40}, []float64{-0.5, 0.5}},
+		{formatPCM, 32, []byte{0, 0, 0, 0xc0, 0, 0, 0, 0x20}, []float64{-0.5, 0.25}},
+		{formatFloat, 32, float32Bytes(0.75, -0.125), []float64{0.75, -0.125}},
+		{formatExtensible, 16, []byte{0, 0x40, 0, 0xc0}, []float64{0.5, -0.5}},
+	}
+	for i, test := range tests {
+		data := wavBytes(test.tag, 1, 8000, test.bits, test.data, true)
+		r, err := NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if r.SampleRate() != 8000 || r.Channels() != 1 {
+			t.Errorf("test %d: bad format %v", i, r.Format())
+		}
+		actual, err := readAll(r, 2)
+		if err != io.EOF {
+			t.Errorf("test %d: expected EOF but got %v", i, err)
+		}
+		if !samplesEqual(actual, test.expected) {
+			t.Errorf("test %d: expected %v but got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestReaderChunks(t *testing.T) {
+	data := wavBytes(formatPCM, 2, 16000, 16, []byte{0, 0x40, 0, 0xc0}, true)
+
+	// Append a chunk after the data which should not be
+	// read as samples.
+	data = append(data, []byte("LIST\x02\x00\x00\x00ab")...)
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Channels() != 2 || r.SampleRate() != 16000 {
+		t.Errorf("bad format %v", r.Format())
+	}
+	actual, err := readAll(r, 3)
+	if err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+	if expected := []float64{0.5, -0.5}; !samplesEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestReaderTruncated(t *testing.T) {
+	data := wavBytes(formatPCM, 1, 8000, 16, []byte{0, 0x40, 0, 0xc0, 0, 0}, true)
+	r, err := NewReader(bytes.NewReader(data[:len(data)-3]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := readAll(r, 10)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected unexpected EOF but got %v", err)
+	}
+	if expected := []float64{0.5}; !samplesEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+
+	if _, err := NewReader(bytes.NewReader(data[:30])); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected unexpected EOF for header but got %v", err)
+	}
+}
+
+func TestReaderPartialSample(t *testing.T) {
+	// The data chunk's size ends in the middle of a sample,
+	// even though the file continues.
+	data := wavBytes(formatPCM, 1, 8000, 16, []byte{0, 0x40, 0, 0xc0, 0}, true)
+	data = append(data, 0, 0, 0)
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := readAll(r, 10)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected unexpected EOF but got %v", err)
+	}
+	if expected := []float64{0.5, -0.5}; !samplesEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestReaderEmpty(t *testing.T) {
+	data := wavBytes(formatPCM, 1, 8000, 16, nil, true)
+
+	// Trailing bytes after an empty data chunk are not
+	// samples.
+	data = append(data, 0, 0x40)
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := readAll(r, 2)
+	if err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+	if len(actual) != 0 {
+		t.Errorf("expected no samples but got %v", actual)
+	}
+}
+
+func TestReaderFormatSize(t *testing.T) {
+	data := wavBytes(formatPCM, 1, 8000, 16, []byte{0, 0x40}, true)
+
+	// Pad the fmt chunk with odd-sized trailing data,
+	// which should be skipped.
+	var padded []byte
+	padded = append(padded, data[:16]...)
+	padded = append(padded, 16+51, 0, 0, 0)
+	padded = append(padded, data[20:36]...)
+	padded = append(padded, make([]byte, 52)...)
+	padded = append(padded, data[36:]...)
+	r, err := NewReader(bytes.NewReader(padded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := readAll(r, 2)
+	if err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+	if expected := []float64{0.5}; !samplesEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+
+	huge := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(huge[16:], 0xfffffff0)
+	if _, err := NewReader(bytes.NewReader(huge)); err == nil {
+		t.Error("expected error for huge fmt chunk")
+	}
+}
+
+func TestReaderUnknownSize(t *testing.T) {
+	data := wavBytes(formatPCM, 1, 8000, 16, []byte{0, 0x40, 0, 0xc0, 0, 0}, false)
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := readAll(r, 2)
+	if err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+	if expected := []float64{0.5, -0.5, 0}; !samplesEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestRawReader(t *testing.T) {
+	format := Format{SampleRate: 8000, Channels: 1, BitsPerSample: 24}
+	r := NewRawReader(bytes.NewReader([]byte{0, 0, 0x40, 0, 0, 0xe0, 0, 0}), format)
+	actual, err := readAll(r, 1)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected unexpected EOF but got %v", err)
+	}
+	if expected := []float64{0.5, -0.25}; !samplesEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
+
+func readAll(r *Reader, bufSize int) ([]float64, error) {
+	var res []float64
+	buf := make([]float64, bufSize)
+	for {
+		n, err := r.ReadSamples(buf)
+		res = append(res, buf[:n]...)
+		if err != nil {
+			return res, err
+		}
+	}
+}
+
+// wavBytes encodes a WAV file.
+// If sized is false, the sizes in the header are set to
+// 0xFFFFFFFF, as a streaming encoder would.
+func wavBytes(tag uint16, channels, rate, bits int, data []byte, sized bool) []byte {
+	var fmtChunk bytes.Buffer
+	header := []interface{}{
+		tag, uint16(channels), uint32(rate),
+		uint32(rate * channels * bits / 8), uint16(channels * bits / 8), uint16(bits),
+	}
+	for _, x := range header {
+		binary.Write(&fmtChunk, binary.LittleEndian, x)
+	}
+	if tag == formatExtensible {
+		binary.Write(&fmtChunk, binary.LittleEndian, uint16(22))
+		binary.Write(&fmtChunk, binary.LittleEndian, uint16(bits))
+		binary.Write(&fmtChunk, binary.LittleEndian, uint32(0))
+		binary.Write(&fmtChunk, binary.LittleEndian, uint16(formatPCM))
+		fmtChunk.Write(make([]byte, 14))
+	}
+
+	var res bytes.Buffer
+	riffSize, dataSize := uint32(unknownDataSize), uint32(unknownDataSize)
+	if sized {
+		dataSize = uint32(len(data))
+		riffSize = uint32(4 + 8 + fmtChunk.Len() + 8 + len(data))
+	}
+	res.WriteString("RIFF")
+	binary.Write(&res, binary.LittleEndian, riffSize)
+	res.WriteString("WAVEfmt ")
+	binary.Write(&res, binary.LittleEndian, uint32(fmtChunk.Len()))
+	res.Write(fmtChunk.Bytes())
+
+	// An odd-sized chunk tests padding.
+	res.WriteString("junk\x03\x00\x00\x00abc\x00")
+
+	res.WriteString("data")
+	binary.Write(&res, binary.LittleEndian, dataSize)
+	res.Write(data)
+	return res.Bytes()
+}
+
+func float32Bytes(vals ...float32) []byte {
+	var res []byte
+	for _, x := range vals {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(x))
+		res = append(res, b[:]...)
+	}
+	return res
+}
+
+func samplesEqual(s1, s2 []float64) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i, x := range s1 {
+		if math.Abs(x-s2[i]) > 1e-8 {
+			return false
+		}
+	}
+	return true
+}
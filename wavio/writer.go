@@ -0,0 +1,195 @@
+package wavio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+)
+
+// headerSize is the size of the header written by a
+// Writer, up to the start of the sample data.
+const headerSize = 44
+
+// maxDataSize is the largest data chunk which fits in a
+// WAV file.
+const maxDataSize = math.MaxUint32 - headerSize
+
+// A Writer encodes samples as a WAV file.
+// It implements mfcc.Sink.
+//
+// The header is written before any samples.
+// If the underlying writer is an io.WriteSeeker, Close
+// goes back and fills in the sizes in the header.
+// Otherwise, the sizes are left as 0xFFFFFFFF, which
+// Reader and most other programs treat as "read until
+// the end of the file".
+type Writer struct {
+	w      io.Writer
+	format Format
+	closer io.Closer
+
+	dataSize  int64
+	buffer    []byte
+	doneError error
+}
+
+// NewWriter creates a Writer and writes the WAV header.
+// Samples are interleaved in the same way as for Reader.
+//
+// Closing the Writer does not close w.
+func NewWriter(w io.Writer, format Format) (*Writer, error) {
+	if err := format.validate(); err != nil {
+		return nil, err
+	}
+	res := &Writer{w: w, format: format}
+	if _, err := w.Write(res.header(unknownDataSize)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Create creates a file and returns a Writer for it.
+// Closing the Writer closes the file.
+func Create(path string, format Format) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	res, err := NewWriter(f, format)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	res.closer = f
+	return res, nil
+}
+
+// Format returns the encoding of the samples.
+func (w *Writer) Format() Format {
+	return w.format
+}
+
+// WriteSamples encodes samples.
+// PCM samples are clipped to the range [-1, 1].
+func (w *Writer) WriteSamples(s []float64) (n int, err error) {
+	if w.doneError != nil {
+		return 0, w.doneError
+	}
+	size := w.format.sampleSize()
+	if w.dataSize+int64(len(s)*size) > maxDataSize {
+		return 0, errors.New("too much data for WAV file")
+	}
+	if len(w.buffer) < len(s)*size {
+		w.buffer = make([]byte, len(s)*size)
+	}
+	buf := w.buffer[:len(s)*size]
+	for i, x := range s {
+		w.encode(buf[i*size:], x)
+	}
+	written, err := w.w.Write(buf)
+	w.dataSize += int64(written)
+	if err != nil {
+		w.doneError = err
+	}
+	return written / size, err
+}
+
+// Close finishes the file.
+// The Writer may not be used after it is closed.
+func (w *Writer) Close() error {
+	if w.doneError == errWriterClosed {
+		return w.doneError
+	}
+	err := w.finish()
+	if w.closer != nil {
+		if closeErr := w.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	w.doneError = errWriterClosed
+	return err
+}
+
+var errWriterClosed = errors.New("WAV writer is closed")
+
+func (w *Writer) finish() error {
+	if w.doneError != nil {
+		return w.doneError
+	}
+	if w.dataSize%2 == 1 {
+		if _, err := w.w.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	seeker, ok := w.w.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+	end, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	start := end - w.dataSize - w.dataSize%2 - headerSize
+	if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := seeker.Write(w.header(uint32(w.dataSize))); err != nil {
+		return err
+	}
+	_, err = seeker.Seek(end, io.SeekStart)
+	return err
+}
+
+func (w *Writer) header(dataSize uint32) []byte {
+	res := make([]byte, headerSize)
+	copy(res, "RIFF")
+	riffSize := uint32(unknownDataSize)
+	if dataSize != unknownDataSize {
+		riffSize = headerSize - 8 + dataSize + dataSize%2
+	}
+	binary.LittleEndian.PutUint32(res[4:], riffSize)
+	copy(res[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(res[16:], 16)
+	tag := uint16(formatPCM)
+	if w.format.Float {
+		tag = formatFloat
+	}
+	blockSize := w.format.Channels * w.format.sampleSize()
+	binary.LittleEndian.PutUint16(res[20:], tag)
+	binary.LittleEndian.PutUint16(res[22:], uint16(w.format.Channels))
+	binary.LittleEndian.PutUint32(res[24:], uint32(w.format.SampleRate))
+	binary.LittleEndian.PutUint32(res[28:], uint32(w.format.SampleRate*blockSize))
+	binary.LittleEndian.PutUint16(res[32:], uint16(blockSize))
+	binary.LittleEndian.PutUint16(res[34:], uint16(w.format.BitsPerSample))
+	copy(res[36:], "data")
+	binary.LittleEndian.PutUint32(res[40:], dataSize)
+	return res
+}
+
+func (w *Writer) encode(b []byte, x float64) {
+	if w.format.Float {
+		binary.LittleEndian.PutUint32(b, math.Float32bits(float32(x)))
+		return
+	}
+	bits := uint(w.format.BitsPerSample)
+	scale := math.Ldexp(1, int(bits-1))
+	value := int64(math.Floor(x*scale + 0.5))
+	if max := int64(scale) - 1; value > max {
+		value = max
+	} else if value < -int64(scale) {
+		value = -int64(scale)
+	}
+	switch bits {
+	case 8:
+		b[0] = byte(value + 128)
+	case 16:
+		binary.LittleEndian.PutUint16(b, uint16(value))
+	case 24:
+		b[0], b[1], b[2] = byte(value), byte(value>>8), byte(value>>16)
+	default:
+		binary.LittleEndian.PutUint32(b, uint32(value))
+	}
+}
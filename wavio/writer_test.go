@@ -0,0 +1,114 @@
+package wavio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unixpickle/speechrecog/mfcc"
+)
+
+var _ mfcc.Sink = &Writer{}
+
+func TestWriterRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wavio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	samples := []float64{0, 0.5, -0.25, 1, -1, 0.1, -0.7}
+	formats := []Format{
+		{SampleRate: 16000, Channels: 1, BitsPerSample: 16},
+		{SampleRate: 8000, Channels: 1, BitsPerSample: 8},
+		{SampleRate: 44100, Channels: 1, BitsPerSample: 24},
+		{SampleRate: 22050, Channels: 1, BitsPerSample: 32},
+		{SampleRate: 16000, Channels: 1, BitsPerSample: 32, Float: true},
+	}
+	for i, format := range formats {
+		path := filepath.Join(dir, "test.wav")
+		w, err := Create(path, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.WriteSamples(samples[:3]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.WriteSamples(samples[3:]); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Append a chunk to make sure that the data size
+		// in the header was filled in.
+		data = append(data, []byte("LIST\x02\x00\x00\x00ab")...)
+		r, err := NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("format %d: %v", i, err)
+		}
+		if r.Format() != format {
+			t.Errorf("format %d: got format %v", i, r.Format())
+		}
+		actual, err := readAll(r, 4)
+		if err != io.EOF {
+			t.Errorf("format %d: expected EOF but got %v", i, err)
+		}
+		tolerance := 1 / math.Ldexp(1, format.BitsPerSample-1)
+		if format.Float {
+			tolerance = 1e-7
+		}
+		if len(actual) != len(samples) {
+			t.Errorf("format %d: expected %d samples but got %d", i, len(samples),
+				len(actual))
+			continue
+		}
+		for j, x := range samples {
+			if math.Abs(x-actual[j]) > tolerance {
+				t.Errorf("format %d: sample %d should be %f but got %f", i, j, x, actual[j])
+			}
+		}
+	}
+}
+
+func TestWriterStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	format := Format{SampleRate: 16000, Channels: 2, BitsPerSample: 16}
+	w, err := NewWriter(&buf, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := []float64{0.5, -0.5, 0.25, 2}
+	if _, err := mfcc.Copy(w, &mfcc.SliceSource{Slice: samples}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteSamples(samples); err == nil {
+		t.Error("expected error after close")
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := readAll(r, 3)
+	if err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+	expected := []float64{0.5, -0.5, 0.25, 32767.0 / 32768}
+	if !samplesEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}